@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
 	"github.com/ozaanmetin/go-microservice-starter/internal/api"
 	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
 	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
 	infrahttp "github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http"
+	infraredis "github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/redis"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/secretbox"
 )
 
 func main() {
@@ -30,6 +36,12 @@ func main() {
 	}
 	defer logging.L().Sync()
 
+	// Run a subcommand instead of the server, e.g. `server migrate up`
+	if len(os.Args) > 1 {
+		runCommand(cfg, os.Args[1:])
+		return
+	}
+
 	// Setup database connection
 	logging.L().Info("Connecting to database...")
 	db, err := database.WaitForDB(&cfg.Database, 5, 2*time.Second)
@@ -39,8 +51,89 @@ func main() {
 	defer database.Close(db)
 	logging.L().Info("Database connected successfully")
 
+	// Apply pending migrations, replicas starting together wait on the migration lock
+	if cfg.Database.AutoMigrate {
+		logging.L().Info("Applying database migrations...")
+		if err := database.Migrate(context.Background(), db); err != nil {
+			logging.L().WithError(err).Fatal("Failed to migrate database")
+		}
+	}
+
+	// Setup read replica routing, reads fall back to the primary without healthy replicas
+	dbRouter, err := database.NewRouter(db, &cfg.Database)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to setup database replicas")
+	}
+	defer dbRouter.Close()
+	go dbRouter.WatchReplicas(cfg.Database.ReplicaRouting.HealthCheckInterval)
+
+	// Export connection pool statistics next to the HTTP metrics
+	if err := dbRouter.RegisterMetrics(); err != nil {
+		logging.L().WithError(err).Fatal("Failed to register database metrics")
+	}
+
+	// Setup transaction manager
+	txManager, err := database.NewTxManager(db, &cfg.Database.Transaction)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to create transaction manager")
+	}
+
+	// Setup redis connection
+	logging.L().Info("Connecting to redis...")
+	redisClient, err := infraredis.NewClient(&cfg.Redis)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to connect to redis")
+	}
+	defer redisClient.Close()
+	logging.L().Info("Redis connected successfully")
+
+	// Setup JWT manager
+	jwtManager, err := api.NewJWTManager(&cfg.JWT, redisClient)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to create JWT manager")
+	}
+	go watchJWTKeys(jwtManager, cfg.JWT.KeyReloadInterval)
+
+	// Setup mailer
+	mailer, err := api.NewMailer(&cfg.Mail)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to create mailer")
+	}
+
+	// Setup password hashing
+	passwordHasher, err := api.NewPasswordHasher(&cfg.Auth.PasswordHashing)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to create password hasher")
+	}
+
+	// Setup breached password check, nil when disabled
+	breachChecker, err := api.NewBreachChecker(&cfg.Auth.PasswordPolicy.BreachCheck)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to create breached password checker")
+	}
+
+	// Setup encryption of secrets at rest
+	secretBox, err := secretbox.NewFromBase64(cfg.Auth.MFA.EncryptionKey)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to create MFA secret box")
+	}
+
+	// Purge soft deleted users once their retention window has passed
+	if cfg.Auth.UserDeletion.PurgeInterval > 0 {
+		go purgeDeletedUsers(user.NewRepository(dbRouter), cfg.Auth.UserDeletion.Retention, cfg.Auth.UserDeletion.PurgeInterval)
+	}
+
 	// Create HTTP server with route setup from api layer
-	server := infrahttp.NewServer(cfg, api.NewRouteSetup(cfg, db))
+	server := infrahttp.NewServer(cfg, api.NewRouteSetup(cfg, api.Dependencies{
+		DB:             dbRouter,
+		TxManager:      txManager,
+		Redis:          redisClient,
+		JWTManager:     jwtManager,
+		Mailer:         mailer,
+		PasswordHasher: passwordHasher,
+		BreachChecker:  breachChecker,
+		SecretBox:      secretBox,
+	}))
 
 	// Start server in goroutine
 	go func() {
@@ -53,6 +146,16 @@ func main() {
 	gracefulShutdown(server, cfg.Server.ShutdownTimeout)
 }
 
+// runCommand runs the command line subcommand named by args[0]
+func runCommand(cfg *config.Config, args []string) {
+	switch args[0] {
+	case "migrate":
+		runMigrate(cfg, args[1:])
+	default:
+		logging.L().WithField("command", args[0]).Fatal("Unknown command, available commands: migrate")
+	}
+}
+
 // gracefulShutdown handles graceful shutdown on OS signals
 func gracefulShutdown(server *infrahttp.Server, shutdownTimeout time.Duration) {
 	sigChan := make(chan os.Signal, 1)
@@ -67,3 +170,67 @@ func gracefulShutdown(server *infrahttp.Server, shutdownTimeout time.Duration) {
 
 	logging.L().Info("Server gracefully stopped!")
 }
+
+// watchJWTKeys reloads the JWT key ring on SIGHUP and, if interval is set, periodically
+// Keys are read from a freshly loaded configuration so signing keys can be rotated without a restart
+func watchJWTKeys(jwtManager *pkgJWT.Manager, interval time.Duration) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-sigChan:
+		case <-tick:
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			logging.L().WithError(err).Error("Failed to reload configuration for JWT keys")
+			continue
+		}
+
+		keyRing, err := api.LoadKeyRing(&cfg.JWT)
+		if err != nil {
+			logging.L().WithError(err).Error("Failed to reload JWT keys")
+			continue
+		}
+
+		jwtManager.SetKeyRing(keyRing)
+		logging.L().
+			WithField("signing_key", keyRing.Current().ID()).
+			WithField("keys", keyIDs(keyRing)).
+			Info("JWT keys reloaded")
+	}
+}
+
+// purgeDeletedUsers permanently removes users soft deleted longer than retention ago, every interval
+func purgeDeletedUsers(userRepo user.Repository, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := userRepo.PurgeDeleted(context.Background(), time.Now().UTC().Add(-retention))
+		if err != nil {
+			logging.L().WithError(err).Error("Failed to purge deleted users")
+		} else if purged > 0 {
+			logging.L().WithField("count", purged).Info("Purged deleted users")
+		}
+
+		<-ticker.C
+	}
+}
+
+func keyIDs(keyRing *pkgJWT.KeyRing) string {
+	ids := make([]string, 0)
+	for _, key := range keyRing.Keys() {
+		ids = append(ids, key.ID())
+	}
+	return strings.Join(ids, ",")
+}
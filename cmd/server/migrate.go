@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down            roll back the latest migration
+  status          list migrations and whether they are applied
+  redo            roll back and re-apply the latest migration
+  reset           roll back all migrations
+  create <name>   create a new SQL migration in ` + database.MigrationsDir
+
+// runMigrate runs a migrate subcommand against the configured database
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 || !isMigrateCommand(args[0]) {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	// Creating a migration only writes a file and needs no database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			os.Exit(2)
+		}
+		goose.SetSequential(true)
+		if err := goose.Create(nil, database.MigrationsDir, args[1], "sql"); err != nil {
+			logging.L().WithError(err).Fatal("Failed to create migration")
+		}
+		return
+	}
+
+	db, err := database.WaitForDB(&cfg.Database, 5, 2*time.Second)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to connect to database")
+	}
+	defer database.Close(db)
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		logging.L().WithError(err).Fatal("Failed to create migrator")
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		results, err := migrator.Up(ctx)
+		logMigrationResults(results, err)
+	case "down":
+		result, err := migrator.Down(ctx)
+		logMigrationResults([]*goose.MigrationResult{result}, err)
+	case "redo":
+		result, err := migrator.Down(ctx)
+		logMigrationResults([]*goose.MigrationResult{result}, err)
+		result, err = migrator.UpByOne(ctx)
+		logMigrationResults([]*goose.MigrationResult{result}, err)
+	case "reset":
+		results, err := migrator.DownTo(ctx, 0)
+		logMigrationResults(results, err)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logging.L().WithError(err).Fatal("Failed to get migration status")
+		}
+		printMigrationStatus(statuses)
+	}
+}
+
+func isMigrateCommand(command string) bool {
+	switch command {
+	case "up", "down", "redo", "reset", "status", "create":
+		return true
+	default:
+		return false
+	}
+}
+
+// logMigrationResults logs the applied migrations and exits when the command failed
+func logMigrationResults(results []*goose.MigrationResult, err error) {
+	for _, result := range results {
+		if result != nil {
+			database.LogMigrationResult(result)
+		}
+	}
+
+	if err != nil {
+		logging.L().WithError(err).Fatal("Migration command failed")
+	}
+}
+
+func printMigrationStatus(statuses []*goose.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tMIGRATION\tSTATE\tAPPLIED AT")
+
+	for _, status := range statuses {
+		appliedAt := "-"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(
+			w,
+			"%d\t%s\t%s\t%s\n",
+			status.Source.Version,
+			filepath.Base(status.Source.Path),
+			status.State,
+			appliedAt,
+		)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,9 @@
+// Package db holds the SQL migrations, embedded so the server binary can apply them itself
+package db
+
+import "embed"
+
+// Migrations contains the goose SQL migrations under migrations/
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS lockout_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    scope VARCHAR(20) NOT NULL,
+    identifier VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45),
+    failed_attempts INTEGER NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_lockout_events_user_id ON lockout_events(user_id);
+CREATE INDEX idx_lockout_events_created_at ON lockout_events(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS lockout_events;
+-- +goose StatementEnd
@@ -0,0 +1,239 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/pagination"
+)
+
+// List related structs
+
+type ListUsersRequest struct {
+	pagination.Request
+	Email         string     `query:"email" validate:"omitempty,max=255"`
+	IsActive      *bool      `query:"is_active"`
+	EmailVerified *bool      `query:"email_verified"`
+	CreatedAfter  *time.Time `query:"created_after"`
+	CreatedBefore *time.Time `query:"created_before"`
+}
+
+type ListUsersResponse struct {
+	Users []*UserResponse `json:"users"`
+	pagination.Meta
+}
+
+// Single user related structs
+
+type GetUserRequest struct {
+	ID int64 `params:"id" validate:"required,min=1"`
+}
+
+type UpdateUserRequest struct {
+	ID        int64   `params:"id" validate:"required,min=1"`
+	Email     *string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	FirstName *string `json:"first_name,omitempty" validate:"omitempty,max=100"`
+	LastName  *string `json:"last_name,omitempty" validate:"omitempty,max=100"`
+}
+
+type SetUserActiveRequest struct {
+	ID int64 `params:"id" validate:"required,min=1"`
+}
+
+type DeleteUserRequest struct {
+	ID int64 `params:"id" validate:"required,min=1"`
+}
+
+type DeleteUserResponse struct {
+	Message string `json:"message"`
+}
+
+// User related structs
+
+type UserResponse struct {
+	ID              int64      `json:"id"`
+	Email           string     `json:"email"`
+	FirstName       *string    `json:"first_name,omitempty"`
+	LastName        *string    `json:"last_name,omitempty"`
+	IsActive        bool       `json:"is_active"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// toUserResponse converts a user entity to response format
+func toUserResponse(u *user.User) *UserResponse {
+	return &UserResponse{
+		ID:              u.ID,
+		Email:           u.Email,
+		FirstName:       u.FirstName,
+		LastName:        u.LastName,
+		IsActive:        u.IsActive,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+	}
+}
+
+// toServiceError maps user service errors to API errors
+func toServiceError(err error) error {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		return appErrors.NewNotFoundError("User not found", err)
+	case errors.Is(err, user.ErrUserAlreadyExists):
+		return appErrors.NewConflictError("User with this email already exists", err)
+	case errors.Is(err, ErrCannotModifySelf):
+		return appErrors.NewBadRequestError("You cannot deactivate or delete your own account", err)
+	case errors.Is(err, pagination.ErrInvalidSort):
+		return appErrors.NewValidationError("Invalid sort", err).
+			AddDetail("sort", map[string]string{"rule": "sort", "message": err.Error()})
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		return appErrors.NewValidationError("Invalid cursor", err).
+			AddDetail("cursor", map[string]string{"rule": "cursor", "message": err.Error()})
+	case errors.Is(err, pagination.ErrInvalidPage):
+		return appErrors.NewValidationError("Invalid page", err).
+			AddDetail("page", map[string]string{"rule": "page", "message": err.Error()})
+	default:
+		return appErrors.NewInternalServerError(err)
+	}
+}
+
+// List Users Handler returns a paginated list of users
+
+type ListUsersHandler struct {
+	service *UserService
+}
+
+func NewListUsersHandler(service *UserService) *ListUsersHandler {
+	return &ListUsersHandler{service: service}
+}
+
+func (h *ListUsersHandler) Handle(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error) {
+	query, err := user.ListSpec.Query(req.Request)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	users, total, err := h.service.ListUsers(ctx, user.ListFilter{
+		Email:         req.Email,
+		IsActive:      req.IsActive,
+		EmailVerified: req.EmailVerified,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+	}, query)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	users, meta := query.Paginate(users, total)
+
+	items := make([]*UserResponse, 0, len(users))
+	for _, u := range users {
+		items = append(items, toUserResponse(u))
+	}
+
+	return &ListUsersResponse{
+		Users: items,
+		Meta:  meta,
+	}, nil
+}
+
+// Get User Handler returns a single user
+
+type GetUserHandler struct {
+	service *UserService
+}
+
+func NewGetUserHandler(service *UserService) *GetUserHandler {
+	return &GetUserHandler{service: service}
+}
+
+func (h *GetUserHandler) Handle(ctx context.Context, req *GetUserRequest) (*UserResponse, error) {
+	u, err := h.service.GetUser(ctx, req.ID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return toUserResponse(u), nil
+}
+
+// Update User Handler edits a user's email and name
+
+type UpdateUserHandler struct {
+	service *UserService
+}
+
+func NewUpdateUserHandler(service *UserService) *UpdateUserHandler {
+	return &UpdateUserHandler{service: service}
+}
+
+func (h *UpdateUserHandler) Handle(ctx context.Context, req *UpdateUserRequest) (*UserResponse, error) {
+	u, err := h.service.UpdateUser(ctx, req.ID, UserUpdate{
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return toUserResponse(u), nil
+}
+
+// Set User Active Handler activates or deactivates a user
+
+type SetUserActiveHandler struct {
+	service *UserService
+	active  bool
+}
+
+func NewActivateUserHandler(service *UserService) *SetUserActiveHandler {
+	return &SetUserActiveHandler{service: service, active: true}
+}
+
+func NewDeactivateUserHandler(service *UserService) *SetUserActiveHandler {
+	return &SetUserActiveHandler{service: service, active: false}
+}
+
+func (h *SetUserActiveHandler) Handle(ctx context.Context, req *SetUserActiveRequest) (*UserResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	u, err := h.service.SetActive(ctx, claims.UserID, req.ID, h.active)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return toUserResponse(u), nil
+}
+
+// Delete User Handler removes a user
+
+type DeleteUserHandler struct {
+	service *UserService
+}
+
+func NewDeleteUserHandler(service *UserService) *DeleteUserHandler {
+	return &DeleteUserHandler{service: service}
+}
+
+func (h *DeleteUserHandler) Handle(ctx context.Context, req *DeleteUserRequest) (*DeleteUserResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	if err := h.service.DeleteUser(ctx, claims.UserID, req.ID); err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &DeleteUserResponse{
+		Message: "User deleted successfully",
+	}, nil
+}
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/lockout"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/pagination"
+)
+
+// LockoutService exposes login lockout events to admins
+type LockoutService struct {
+	eventRepo lockout.Repository
+}
+
+func NewLockoutService(eventRepo lockout.Repository) *LockoutService {
+	return &LockoutService{eventRepo: eventRepo}
+}
+
+// ListEvents returns a page of the lockout events matching the filter and the number of all matching events
+func (s *LockoutService) ListEvents(ctx context.Context, filter lockout.ListFilter, query *pagination.Query[*lockout.Event]) ([]*lockout.Event, int64, error) {
+	return s.eventRepo.List(ctx, filter, query)
+}
+
+
+// List lockout events related structs
+
+type ListLockoutEventsRequest struct {
+	pagination.Request
+	UserID       *int64     `query:"user_id" validate:"omitempty,min=1"`
+	Scope        string     `query:"scope" validate:"omitempty,oneof=account ip"`
+	Identifier   string     `query:"identifier" validate:"omitempty,max=255"`
+	CreatedAfter *time.Time `query:"created_after"`
+}
+
+type ListLockoutEventsResponse struct {
+	Events []*lockout.Event `json:"events"`
+	pagination.Meta
+}
+
+
+// List Lockout Events Handler returns a paginated list of login lockouts
+
+type ListLockoutEventsHandler struct {
+	service *LockoutService
+}
+
+func NewListLockoutEventsHandler(service *LockoutService) *ListLockoutEventsHandler {
+	return &ListLockoutEventsHandler{service: service}
+}
+
+func (h *ListLockoutEventsHandler) Handle(ctx context.Context, req *ListLockoutEventsRequest) (*ListLockoutEventsResponse, error) {
+	query, err := lockout.ListSpec.Query(req.Request)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	events, total, err := h.service.ListEvents(ctx, lockout.ListFilter{
+		UserID:       req.UserID,
+		Scope:        lockout.Scope(req.Scope),
+		Identifier:   req.Identifier,
+		CreatedAfter: req.CreatedAfter,
+	}, query)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	events, meta := query.Paginate(events, total)
+
+	return &ListLockoutEventsResponse{
+		Events: events,
+		Meta:   meta,
+	}, nil
+}
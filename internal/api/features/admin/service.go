@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/usertoken"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/pagination"
+)
+
+var (
+	ErrCannotModifySelf = errors.New("admins cannot deactivate or delete their own account")
+)
+
+// UserUpdate holds the optional fields an admin can change on a user
+type UserUpdate struct {
+	Email     *string
+	FirstName *string
+	LastName  *string
+}
+
+// UserService provides admin user-management operations
+type UserService struct {
+	userRepo   user.Repository
+	tokenRepo  usertoken.Repository
+	jwtManager *pkgJWT.Manager
+}
+
+func NewUserService(userRepo user.Repository, tokenRepo usertoken.Repository, jwtManager *pkgJWT.Manager) *UserService {
+	return &UserService{
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		jwtManager: jwtManager,
+	}
+}
+
+// ListUsers returns a page of the users matching the filter and the number of all matching users
+func (s *UserService) ListUsers(ctx context.Context, filter user.ListFilter, query *pagination.Query[*user.User]) ([]*user.User, int64, error) {
+	return s.userRepo.List(ctx, filter, query)
+}
+
+// GetUser returns a single user
+func (s *UserService) GetUser(ctx context.Context, id int64) (*user.User, error) {
+	return s.userRepo.GetByID(ctx, id)
+}
+
+// UpdateUser applies the non-nil fields of update to the user
+func (s *UserService) UpdateUser(ctx context.Context, id int64, update UserUpdate) (*user.User, error) {
+	ctx = database.WithPrimary(ctx)
+
+	existingUser, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Email != nil && *update.Email != existingUser.Email {
+		// Make sure the new email is not taken by another user
+		other, err := s.userRepo.GetByEmail(ctx, *update.Email)
+		if err == nil && other.ID != existingUser.ID {
+			return nil, user.ErrUserAlreadyExists
+		}
+		if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+			return nil, err
+		}
+		existingUser.Email = *update.Email
+		// Ownership of the new address has not been proven yet
+		existingUser.EmailVerifiedAt = nil
+
+		// Links mailed to the old address must not verify the new one or reset its password
+		for _, purpose := range []usertoken.Purpose{usertoken.PurposeEmailVerification, usertoken.PurposePasswordReset} {
+			if err := s.tokenRepo.InvalidateForUser(ctx, existingUser.ID, purpose); err != nil {
+				return nil, fmt.Errorf("failed to invalidate user tokens: %w", err)
+			}
+		}
+	}
+
+	if update.FirstName != nil {
+		existingUser.FirstName = update.FirstName
+	}
+	if update.LastName != nil {
+		existingUser.LastName = update.LastName
+	}
+
+	if err := s.userRepo.Update(ctx, existingUser); err != nil {
+		return nil, err
+	}
+
+	return existingUser, nil
+}
+
+// SetActive activates or deactivates a user
+// Deactivated users are logged out of every session, Login already rejects them
+func (s *UserService) SetActive(ctx context.Context, actorID, id int64, active bool) (*user.User, error) {
+	if !active && actorID == id {
+		return nil, ErrCannotModifySelf
+	}
+
+	ctx = database.WithPrimary(ctx)
+
+	existingUser, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	existingUser.IsActive = active
+	if err := s.userRepo.Update(ctx, existingUser); err != nil {
+		return nil, err
+	}
+
+	if !active {
+		if err := s.jwtManager.RevokeUser(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to revoke user sessions: %w", err)
+		}
+	}
+
+	return existingUser, nil
+}
+
+// DeleteUser soft deletes a user and revokes all of their sessions
+// The account is purged permanently once the retention window has passed
+func (s *UserService) DeleteUser(ctx context.Context, actorID, id int64) error {
+	if actorID == id {
+		return ErrCannotModifySelf
+	}
+
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.jwtManager.RevokeUser(ctx, id); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
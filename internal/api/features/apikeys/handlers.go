@@ -0,0 +1,226 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/apikey"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+)
+
+// Create related structs
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey *APIKeyResponse `json:"api_key"`
+	Key    string          `json:"key"`
+}
+
+func (r *CreateAPIKeyResponse) StatusCode() int {
+	return 201
+}
+
+// List related structs
+
+type ListAPIKeysRequest struct{}
+
+type ListUserAPIKeysRequest struct {
+	UserID int64 `params:"id" validate:"required,min=1"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []*APIKeyResponse `json:"api_keys"`
+}
+
+// Revoke related structs
+
+type RevokeAPIKeyRequest struct {
+	ID int64 `params:"id" validate:"required,min=1"`
+}
+
+type RevokeAPIKeyResponse struct {
+	Message string `json:"message"`
+}
+
+// API key related structs
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// toAPIKeyResponse converts an API key entity to response format
+func toAPIKeyResponse(k *apikey.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+func toListResponse(keys []*apikey.APIKey) *ListAPIKeysResponse {
+	responses := make([]*APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		responses = append(responses, toAPIKeyResponse(k))
+	}
+	return &ListAPIKeysResponse{APIKeys: responses}
+}
+
+// toServiceError maps API key service errors to API errors
+func toServiceError(err error) error {
+	switch {
+	case errors.Is(err, apikey.ErrAPIKeyNotFound):
+		return appErrors.NewNotFoundError("API key not found", err)
+	case errors.Is(err, user.ErrUserNotFound):
+		return appErrors.NewNotFoundError("User not found", err)
+	case errors.Is(err, ErrScopeNotAllowed):
+		return appErrors.NewForbiddenError("API key scopes must be a subset of your permissions", err)
+	case errors.Is(err, ErrExpiryInPast):
+		return appErrors.NewValidationError("API key expiry must be in the future", err)
+	case errors.Is(err, ErrAPIKeyPrincipal):
+		return appErrors.NewForbiddenError("API keys cannot be managed with an API key", err)
+	default:
+		return appErrors.NewInternalServerError(err)
+	}
+}
+
+
+// Create API Key Handler issues a new API key for the current user
+
+type CreateAPIKeyHandler struct {
+	service *APIKeyService
+}
+
+func NewCreateAPIKeyHandler(service *APIKeyService) *CreateAPIKeyHandler {
+	return &CreateAPIKeyHandler{service: service}
+}
+
+func (h *CreateAPIKeyHandler) Handle(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	created, err := h.service.Create(ctx, claims, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &CreateAPIKeyResponse{
+		APIKey: toAPIKeyResponse(created.APIKey),
+		Key:    created.Key,
+	}, nil
+}
+
+
+// List API Keys Handler returns the API keys of the current user
+
+type ListAPIKeysHandler struct {
+	service *APIKeyService
+}
+
+func NewListAPIKeysHandler(service *APIKeyService) *ListAPIKeysHandler {
+	return &ListAPIKeysHandler{service: service}
+}
+
+func (h *ListAPIKeysHandler) Handle(ctx context.Context, req *ListAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	keys, err := h.service.List(ctx, claims.UserID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return toListResponse(keys), nil
+}
+
+
+// Revoke API Key Handler revokes one of the current user's API keys
+
+type RevokeAPIKeyHandler struct {
+	service *APIKeyService
+}
+
+func NewRevokeAPIKeyHandler(service *APIKeyService) *RevokeAPIKeyHandler {
+	return &RevokeAPIKeyHandler{service: service}
+}
+
+func (h *RevokeAPIKeyHandler) Handle(ctx context.Context, req *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	if err := h.service.RevokeOwn(ctx, claims, req.ID); err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &RevokeAPIKeyResponse{
+		Message: "API key revoked successfully",
+	}, nil
+}
+
+
+// List User API Keys Handler returns the API keys of any user (admin)
+
+type ListUserAPIKeysHandler struct {
+	service *APIKeyService
+}
+
+func NewListUserAPIKeysHandler(service *APIKeyService) *ListUserAPIKeysHandler {
+	return &ListUserAPIKeysHandler{service: service}
+}
+
+func (h *ListUserAPIKeysHandler) Handle(ctx context.Context, req *ListUserAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	keys, err := h.service.ListForUser(ctx, req.UserID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return toListResponse(keys), nil
+}
+
+
+// Admin Revoke API Key Handler revokes any API key (admin)
+
+type AdminRevokeAPIKeyHandler struct {
+	service *APIKeyService
+}
+
+func NewAdminRevokeAPIKeyHandler(service *APIKeyService) *AdminRevokeAPIKeyHandler {
+	return &AdminRevokeAPIKeyHandler{service: service}
+}
+
+func (h *AdminRevokeAPIKeyHandler) Handle(ctx context.Context, req *RevokeAPIKeyRequest) (*RevokeAPIKeyResponse, error) {
+	if err := h.service.Revoke(ctx, req.ID); err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &RevokeAPIKeyResponse{
+		Message: "API key revoked successfully",
+	}, nil
+}
@@ -0,0 +1,178 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/apikey"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/role"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+)
+
+var (
+	ErrScopeNotAllowed = errors.New("API key scope exceeds the caller's permissions")
+	ErrExpiryInPast    = errors.New("API key expiry must be in the future")
+	ErrAPIKeyPrincipal = errors.New("API keys cannot manage API keys")
+)
+
+// CreatedKey holds a newly created API key, Key is only ever returned here
+type CreatedKey struct {
+	APIKey *apikey.APIKey
+	Key    string
+}
+
+// APIKeyService manages API keys and resolves them to principals
+type APIKeyService struct {
+	apiKeyRepo apikey.Repository
+	userRepo   user.Repository
+	roleRepo   role.Repository
+}
+
+func NewAPIKeyService(apiKeyRepo apikey.Repository, userRepo user.Repository, roleRepo role.Repository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		roleRepo:   roleRepo,
+	}
+}
+
+// Create issues a new API key for the caller
+// Scopes must be a subset of the caller's permissions, API key callers cannot create keys
+func (s *APIKeyService) Create(ctx context.Context, claims *pkgJWT.Claims, name string, scopes []string, expiresAt *time.Time) (*CreatedKey, error) {
+	if claims.TokenType == pkgJWT.APIKeyToken {
+		return nil, ErrAPIKeyPrincipal
+	}
+
+	for _, scope := range scopes {
+		if !claims.HasPermission(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+		}
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrExpiryInPast
+	}
+
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	newKey := &apikey.APIKey{
+		UserID:    claims.UserID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, newKey); err != nil {
+		return nil, err
+	}
+
+	return &CreatedKey{APIKey: newKey, Key: key}, nil
+}
+
+// List returns every API key of a user
+func (s *APIKeyService) List(ctx context.Context, userID int64) ([]*apikey.APIKey, error) {
+	return s.apiKeyRepo.ListByUser(ctx, userID)
+}
+
+// ListForUser returns every API key of an existing user
+func (s *APIKeyService) ListForUser(ctx context.Context, userID int64) ([]*apikey.APIKey, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.apiKeyRepo.ListByUser(ctx, userID)
+}
+
+// RevokeOwn revokes one of the caller's API keys
+// Keys of other users are reported as not found
+func (s *APIKeyService) RevokeOwn(ctx context.Context, claims *pkgJWT.Claims, id int64) error {
+	if claims.TokenType == pkgJWT.APIKeyToken {
+		return ErrAPIKeyPrincipal
+	}
+
+	existingKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if existingKey.UserID != claims.UserID {
+		return apikey.ErrAPIKeyNotFound
+	}
+
+	return s.apiKeyRepo.Revoke(ctx, id)
+}
+
+// Revoke revokes any API key
+func (s *APIKeyService) Revoke(ctx context.Context, id int64) error {
+	return s.apiKeyRepo.Revoke(ctx, id)
+}
+
+// ResolveAPIKey implements middlewares.APIKeyResolver
+// The resolved claims carry the owner's roles and the key's scopes that the
+// owner still holds, so revoking a permission from the owner also limits the key
+func (s *APIKeyService) ResolveAPIKey(ctx context.Context, key string) (*pkgJWT.Claims, error) {
+	existingKey, err := s.apiKeyRepo.GetByHash(ctx, apikey.Hash(key))
+	if err != nil {
+		if errors.Is(err, apikey.ErrAPIKeyNotFound) {
+			return nil, middlewares.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !existingKey.IsUsable(now) {
+		return nil, middlewares.ErrInvalidCredentials
+	}
+
+	owner, err := s.userRepo.GetByID(ctx, existingKey.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil, middlewares.ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !owner.IsActive {
+		return nil, middlewares.ErrInvalidCredentials
+	}
+
+	access, err := s.roleRepo.GetUserAccess(ctx, owner.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user access: %w", err)
+	}
+
+	permissions := make([]string, 0, len(existingKey.Scopes))
+	for _, scope := range existingKey.Scopes {
+		if slices.Contains(access.Permissions, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, existingKey.ID, now); err != nil {
+		logging.L().
+			WithError(err).
+			WithField("api_key_id", existingKey.ID).
+			Warn("Failed to record API key usage")
+	}
+
+	claims := &pkgJWT.Claims{
+		UserID:      owner.ID,
+		Email:       owner.Email,
+		Roles:       access.Roles,
+		Permissions: permissions,
+		TokenType:   pkgJWT.APIKeyToken,
+	}
+	claims.ID = existingKey.Prefix
+
+	return claims, nil
+}
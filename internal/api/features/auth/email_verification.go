@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/usertoken"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/mailer"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+)
+
+// EmailVerificationService issues and consumes single-use email verification tokens
+type EmailVerificationService struct {
+	userRepo  user.Repository
+	tokenRepo usertoken.Repository
+	mailer    mailer.Mailer
+	tokenTTL  time.Duration
+	verifyURL string
+}
+
+func NewEmailVerificationService(
+	userRepo user.Repository,
+	tokenRepo usertoken.Repository,
+	mailer mailer.Mailer,
+	tokenTTL time.Duration,
+	verifyURL string,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		mailer:    mailer,
+		tokenTTL:  tokenTTL,
+		verifyURL: verifyURL,
+	}
+}
+
+// SendVerification emails a verification link to the user
+// Previously issued verification tokens are invalidated
+func (s *EmailVerificationService) SendVerification(ctx context.Context, u *user.User) error {
+	if err := s.tokenRepo.InvalidateForUser(ctx, u.ID, usertoken.PurposeEmailVerification); err != nil {
+		return err
+	}
+
+	token, hash, err := usertoken.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.Create(ctx, &usertoken.UserToken{
+		UserID:    u.ID,
+		Purpose:   usertoken.PurposeEmailVerification,
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(s.tokenTTL),
+	}); err != nil {
+		return err
+	}
+
+	msg := s.verificationMessage(u.Email, token)
+	go func() {
+		if err := s.mailer.Send(context.Background(), msg); err != nil {
+			logging.L().
+				WithError(err).
+				WithField("user_id", u.ID).
+				Error("Failed to send email verification email")
+		}
+	}()
+
+	return nil
+}
+
+// Resend emails a new verification link to the user with the given email
+// Unknown, inactive and already verified accounts are silently ignored so callers cannot enumerate users
+func (s *EmailVerificationService) Resend(ctx context.Context, email string) error {
+	existingUser, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !existingUser.IsActive || existingUser.IsEmailVerified() {
+		return nil
+	}
+
+	return s.SendVerification(ctx, existingUser)
+}
+
+// Verify consumes the token and marks the user's email as verified
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) (*user.User, error) {
+	verificationToken, err := s.tokenRepo.Consume(ctx, usertoken.PurposeEmailVerification, usertoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, usertoken.ErrTokenNotFound) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, err
+	}
+
+	ctx = database.WithPrimary(ctx)
+
+	existingUser, err := s.userRepo.GetByID(ctx, verificationToken.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil, ErrInvalidVerificationToken
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if existingUser.IsEmailVerified() {
+		return existingUser, nil
+	}
+
+	now := time.Now().UTC()
+	existingUser.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(ctx, existingUser); err != nil {
+		return nil, err
+	}
+
+	return existingUser, nil
+}
+
+func (s *EmailVerificationService) verificationMessage(email, token string) mailer.Message {
+	link := appendQuery(s.verifyURL, "token", token)
+
+	return mailer.Message{
+		To:      []string{email},
+		Subject: "Verify your email address",
+		TextBody: fmt.Sprintf(
+			"Thanks for signing up.\n\n"+
+				"Open the link below to verify your email address:\n%s\n\n"+
+				"The link expires in %s. If you did not create an account, you can ignore this email.",
+			link,
+			s.tokenTTL,
+		),
+	}
+}
+
+
+// Verify email related structs
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" query:"token" validate:"required"`
+}
+
+type VerifyEmailResponse struct {
+	Message string        `json:"message"`
+	User    *UserResponse `json:"user"`
+}
+
+// Resend verification related structs
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResendVerificationResponse struct {
+	Message string `json:"message"`
+}
+
+func (r *ResendVerificationResponse) StatusCode() int {
+	return 202
+}
+
+
+// Verify Email Handler confirms ownership of an email address
+
+type VerifyEmailHandler struct {
+	service *EmailVerificationService
+}
+
+func NewVerifyEmailHandler(service *EmailVerificationService) *VerifyEmailHandler {
+	return &VerifyEmailHandler{service: service}
+}
+
+func (h *VerifyEmailHandler) Handle(ctx context.Context, req *VerifyEmailRequest) (*VerifyEmailResponse, error) {
+	verifiedUser, err := h.service.Verify(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidVerificationToken) {
+			return nil, appErrors.NewBadRequestError("Invalid or expired email verification token", err)
+		}
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	return &VerifyEmailResponse{
+		Message: "Email has been verified successfully",
+		User:    toUserResponse(verifiedUser),
+	}, nil
+}
+
+
+// Resend Verification Handler sends a new verification link
+
+type ResendVerificationHandler struct {
+	service *EmailVerificationService
+}
+
+func NewResendVerificationHandler(service *EmailVerificationService) *ResendVerificationHandler {
+	return &ResendVerificationHandler{service: service}
+}
+
+func (h *ResendVerificationHandler) Handle(ctx context.Context, req *ResendVerificationRequest) (*ResendVerificationResponse, error) {
+	if err := h.service.Resend(ctx, req.Email); err != nil {
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	return &ResendVerificationResponse{
+		Message: "If an unverified account with that email exists, a verification link has been sent",
+	}, nil
+}
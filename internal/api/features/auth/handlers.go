@@ -3,24 +3,29 @@ package auth
 import (
 	"context"
 	"errors"
+	"math"
+	"strconv"
 
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/mfa"
 	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
 	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
 	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
 )
 
 // Register related structs
 
 type RegisterRequest struct {
 	Email     string  `json:"email" validate:"required,email"`
-	Password  string  `json:"password" validate:"required,min=8"`
+	Password  string  `json:"password" validate:"required"`
 	FirstName *string `json:"first_name,omitempty"`
 	LastName  *string `json:"last_name,omitempty"`
 }
 
 type RegisterResponse struct {
 	User   *UserResponse     `json:"user"`
-	Tokens *pkgJWT.TokenPair `json:"tokens"`
+	Tokens *pkgJWT.TokenPair `json:"tokens,omitempty"`
 }
 
 func (r *RegisterResponse) StatusCode() int {
@@ -36,8 +41,17 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	User   *UserResponse     `json:"user"`
-	Tokens *pkgJWT.TokenPair `json:"tokens"`
+	User        *UserResponse     `json:"user,omitempty"`
+	Tokens      *pkgJWT.TokenPair `json:"tokens,omitempty"`
+	MFARequired bool              `json:"mfa_required"`
+	MFAToken    string            `json:"mfa_token,omitempty"`
+}
+
+// Verify MFA related structs
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
 }
 
 // Refresh related structs
@@ -50,25 +64,37 @@ type RefreshTokenResponse struct {
 	Tokens *pkgJWT.TokenPair `json:"tokens"`
 }
 
+// Logout related structs
+
+type LogoutRequest struct{}
+
+type LogoutAllRequest struct{}
+
+type LogoutResponse struct {
+	Message string `json:"message"`
+}
+
 
 // User related structs
 
 type UserResponse struct {
-	ID        int64   `json:"id"`
-	Email     string  `json:"email"`
-	FirstName *string `json:"first_name,omitempty"`
-	LastName  *string `json:"last_name,omitempty"`
-	IsActive  bool    `json:"is_active"`
+	ID            int64   `json:"id"`
+	Email         string  `json:"email"`
+	FirstName     *string `json:"first_name,omitempty"`
+	LastName      *string `json:"last_name,omitempty"`
+	IsActive      bool    `json:"is_active"`
+	EmailVerified bool    `json:"email_verified"`
 }
 
 // toUserResponse converts a user entity to response format
 func toUserResponse(u *user.User) *UserResponse {
 	return &UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		FirstName: u.FirstName,
-		LastName:  u.LastName,
-		IsActive:  u.IsActive,
+		ID:            u.ID,
+		Email:         u.Email,
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		IsActive:      u.IsActive,
+		EmailVerified: u.IsEmailVerified(),
 	}
 }
 
@@ -76,11 +102,12 @@ func toUserResponse(u *user.User) *UserResponse {
 // Register Handler handles user registration
 
 type RegisterHandler struct {
-	service *AuthService
+	service      *AuthService
+	verification *EmailVerificationService
 }
 
-func NewRegisterHandler(service *AuthService) *RegisterHandler {
-	return &RegisterHandler{service: service}
+func NewRegisterHandler(service *AuthService, verification *EmailVerificationService) *RegisterHandler {
+	return &RegisterHandler{service: service, verification: verification}
 }
 
 func (h *RegisterHandler) Handle(ctx context.Context, req *RegisterRequest) (*RegisterResponse, error) {
@@ -90,11 +117,26 @@ func (h *RegisterHandler) Handle(ctx context.Context, req *RegisterRequest) (*Re
 		if errors.Is(err, user.ErrUserAlreadyExists) {
 			return nil, appErrors.NewConflictError("User with this email already exists", err)
 		}
+		if policyErr := password.ToServiceError("password", err); policyErr != nil {
+			return nil, policyErr
+		}
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	// sends the verification email
+	if err := h.verification.SendVerification(ctx, newUser); err != nil {
 		return nil, appErrors.NewInternalServerError(err)
 	}
 
+	// unverified users cannot sign in yet, so no tokens are issued
+	if h.service.RequiresEmailVerification() {
+		return &RegisterResponse{
+			User: toUserResponse(newUser),
+		}, nil
+	}
+
 	// generates tokens
-	tokens, err := h.service.jwtManager.GenerateTokenPair(newUser.ID, newUser.Email)
+	tokens, err := h.service.GenerateTokens(ctx, newUser)
 	if err != nil {
 		return nil, appErrors.NewInternalServerError(err)
 	}
@@ -117,21 +159,78 @@ func NewLoginHandler(service *AuthService) *LoginHandler {
 }
 
 func (h *LoginHandler) Handle(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
-	tokens, existingUser, err := h.service.Login(ctx, req.Email, req.Password)
+	result, err := h.service.Login(ctx, req.Email, req.Password)
 	if err != nil {
 		if errors.Is(err, ErrInvalidCredentials) {
 			return nil, appErrors.NewUnauthorizedError("Invalid email or password", err)
 		}
-		if errors.Is(err, ErrUserNotActive) {
-			return nil, appErrors.NewForbiddenError("User account is not active", err)
+		return nil, toLoginError(err)
+	}
+
+	return toLoginResponse(result), nil
+}
+
+
+// Verify MFA Handler completes a two-step login with a TOTP or recovery code
+
+type VerifyMFAHandler struct {
+	service *AuthService
+}
+
+func NewVerifyMFAHandler(service *AuthService) *VerifyMFAHandler {
+	return &VerifyMFAHandler{service: service}
+}
+
+func (h *VerifyMFAHandler) Handle(ctx context.Context, req *VerifyMFARequest) (*LoginResponse, error) {
+	result, err := h.service.VerifyMFA(ctx, req.MFAToken, req.Code)
+	if err != nil {
+		if errors.Is(err, pkgJWT.ErrExpiredToken) {
+			return nil, appErrors.NewUnauthorizedError("MFA token has expired, please log in again", err)
+		}
+		if errors.Is(err, pkgJWT.ErrInvalidToken) || errors.Is(err, pkgJWT.ErrInvalidSignature) ||
+			errors.Is(err, pkgJWT.ErrRevokedToken) || errors.Is(err, ErrInvalidCredentials) {
+			return nil, appErrors.NewUnauthorizedError("Invalid MFA token", err)
+		}
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			return nil, appErrors.NewUnauthorizedError("Invalid two-factor authentication code", err)
+		}
+		return nil, toLoginError(err)
+	}
+
+	return toLoginResponse(result), nil
+}
+
+// toLoginResponse converts a login result to response format
+func toLoginResponse(result *LoginResult) *LoginResponse {
+	if result.MFAToken != "" {
+		return &LoginResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
 		}
-		return nil, appErrors.NewInternalServerError(err)
 	}
 
 	return &LoginResponse{
-		User:   toUserResponse(existingUser),
-		Tokens: tokens,
-	}, nil
+		User:   toUserResponse(result.User),
+		Tokens: result.Tokens,
+	}
+}
+
+// toLoginError maps the login errors shared by both login steps to API errors
+func toLoginError(err error) error {
+	if errors.Is(err, ErrUserNotActive) {
+		return appErrors.NewForbiddenError("User account is not active", err)
+	}
+	if errors.Is(err, ErrEmailNotVerified) {
+		return appErrors.NewForbiddenError("Email address is not verified", err)
+	}
+	var lockedErr *AccountLockedError
+	if errors.As(err, &lockedErr) {
+		retryAfter := int64(math.Ceil(lockedErr.RetryAfter.Seconds()))
+		return appErrors.NewTooManyRequestsError("Too many failed login attempts, try again later", err).
+			AddDetail("retry_after", retryAfter).
+			WithHeader("Retry-After", strconv.FormatInt(retryAfter, 10))
+	}
+	return appErrors.NewInternalServerError(err)
 }
 
 
@@ -151,6 +250,9 @@ func (h *RefreshTokenHandler) Handle(ctx context.Context, req *RefreshTokenReque
 		if errors.Is(err, pkgJWT.ErrExpiredToken) {
 			return nil, appErrors.NewUnauthorizedError("Refresh token has expired", err)
 		}
+		if errors.Is(err, pkgJWT.ErrRevokedToken) || errors.Is(err, pkgJWT.ErrTokenReused) {
+			return nil, appErrors.NewUnauthorizedError("Refresh token has been revoked", err)
+		}
 		if errors.Is(err, pkgJWT.ErrInvalidToken) || errors.Is(err, pkgJWT.ErrInvalidSignature) {
 			return nil, appErrors.NewUnauthorizedError("Invalid refresh token", err)
 		}
@@ -164,3 +266,55 @@ func (h *RefreshTokenHandler) Handle(ctx context.Context, req *RefreshTokenReque
 		Tokens: tokens,
 	}, nil
 }
+
+
+// Logout Handler revokes the session of the current access token
+
+type LogoutHandler struct {
+	service *AuthService
+}
+
+func NewLogoutHandler(service *AuthService) *LogoutHandler {
+	return &LogoutHandler{service: service}
+}
+
+func (h *LogoutHandler) Handle(ctx context.Context, req *LogoutRequest) (*LogoutResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	if err := h.service.Logout(ctx, claims); err != nil {
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	return &LogoutResponse{
+		Message: "Logged out successfully",
+	}, nil
+}
+
+
+// Logout All Handler revokes every session of the current user
+
+type LogoutAllHandler struct {
+	service *AuthService
+}
+
+func NewLogoutAllHandler(service *AuthService) *LogoutAllHandler {
+	return &LogoutAllHandler{service: service}
+}
+
+func (h *LogoutAllHandler) Handle(ctx context.Context, req *LogoutAllRequest) (*LogoutResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	if err := h.service.LogoutAll(ctx, claims.UserID); err != nil {
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	return &LogoutResponse{
+		Message: "Logged out from all sessions successfully",
+	}, nil
+}
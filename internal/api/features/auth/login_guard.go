@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/lockout"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+)
+
+// AccountLockedError is returned when a login is rejected because the account
+// or the client IP is temporarily locked after too many failed attempts
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter)
+}
+
+// LoginGuard protects login against brute-force attacks by counting failed
+// attempts per account and per client IP and locking them temporarily
+type LoginGuard struct {
+	tracker       lockout.Tracker
+	eventRepo     lockout.Repository
+	accountPolicy lockout.Policy
+	ipPolicy      lockout.Policy
+}
+
+func NewLoginGuard(
+	tracker lockout.Tracker,
+	eventRepo lockout.Repository,
+	accountPolicy lockout.Policy,
+	ipPolicy lockout.Policy,
+) *LoginGuard {
+	return &LoginGuard{
+		tracker:       tracker,
+		eventRepo:     eventRepo,
+		accountPolicy: accountPolicy,
+		ipPolicy:      ipPolicy,
+	}
+}
+
+// Check returns an AccountLockedError if the account or the client IP is locked
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	lockedFor, err := g.tracker.LockedFor(ctx, accountSubject(email), ipSubject(ip))
+	if err != nil {
+		return fmt.Errorf("failed to check login lockout: %w", err)
+	}
+
+	if lockedFor > 0 {
+		return &AccountLockedError{RetryAfter: lockedFor}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login against the account and the client IP
+// userID is nil when the email does not belong to any user
+// An AccountLockedError is returned if this attempt locked either of them
+func (g *LoginGuard) RecordFailure(ctx context.Context, email, ip string, userID *int64) error {
+	var lockedFor time.Duration
+
+	attempts := []struct {
+		subject lockout.Subject
+		policy  lockout.Policy
+	}{
+		{accountSubject(email), g.accountPolicy},
+		{ipSubject(ip), g.ipPolicy},
+	}
+
+	for _, attempt := range attempts {
+		failure, err := g.tracker.RecordFailure(ctx, attempt.subject, attempt.policy)
+		if err != nil {
+			return fmt.Errorf("failed to record login failure: %w", err)
+		}
+		if !failure.Locked() {
+			continue
+		}
+
+		g.recordLockout(ctx, attempt.subject, ip, userID, failure)
+		lockedFor = max(lockedFor, failure.LockedFor)
+	}
+
+	if lockedFor > 0 {
+		return &AccountLockedError{RetryAfter: lockedFor}
+	}
+	return nil
+}
+
+// RecordSuccess clears the failed attempts and previous lockouts of the account
+// The client IP keeps its count so one valid account cannot reset an IP probing others
+func (g *LoginGuard) RecordSuccess(ctx context.Context, email string) error {
+	if err := g.tracker.Reset(ctx, accountSubject(email)); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
+
+// recordLockout logs the lockout as a security event and stores it for admins
+// Storing is best effort, the lock itself is already in place
+func (g *LoginGuard) recordLockout(ctx context.Context, subject lockout.Subject, ip string, userID *int64, failure lockout.Failure) {
+	event := &lockout.Event{
+		Scope:          subject.Scope,
+		Identifier:     subject.Identifier,
+		FailedAttempts: failure.Attempts,
+		LockedUntil:    time.Now().UTC().Add(failure.LockedFor),
+	}
+	if subject.Scope == lockout.ScopeAccount {
+		event.UserID = userID
+	}
+	if ip != "" {
+		event.IPAddress = &ip
+	}
+
+	logger := logging.L().
+		WithField("event", "login_lockout").
+		WithField("scope", subject.Scope).
+		WithField("identifier", subject.Identifier).
+		WithField("ip", ip).
+		WithField("failed_attempts", failure.Attempts).
+		WithField("locked_for", failure.LockedFor.String())
+	if event.UserID != nil {
+		logger = logger.WithField("user_id", *event.UserID)
+	}
+	logger.Warn("Too many failed login attempts, locked temporarily")
+
+	if err := g.eventRepo.Create(ctx, event); err != nil {
+		logging.L().WithError(err).Error("Failed to store lockout event")
+	}
+}
+
+func accountSubject(email string) lockout.Subject {
+	return lockout.Subject{Scope: lockout.ScopeAccount, Identifier: strings.ToLower(strings.TrimSpace(email))}
+}
+
+func ipSubject(ip string) lockout.Subject {
+	return lockout.Subject{Scope: lockout.ScopeIP, Identifier: ip}
+}
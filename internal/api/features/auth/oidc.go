@@ -0,0 +1,373 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/identity"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc"
+)
+
+var (
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidOIDCState     = errors.New("invalid or expired login state")
+	ErrOIDCEmailMissing     = errors.New("identity provider did not return an email address")
+	ErrOIDCEmailNotVerified = errors.New("email address of an existing account is not verified by both the identity provider and the account")
+)
+
+// OIDCService signs users in through external OpenID Connect providers
+// External accounts are linked to local users by provider and subject; on the
+// first login they are linked to the user with the same email if both the provider
+// and the user verified it, otherwise a new user is created
+type OIDCService struct {
+	authService  *AuthService
+	userRepo     user.Repository
+	identityRepo identity.Repository
+	stateStore   oidc.StateStore
+	providers    map[string]*oidc.Client
+	stateTTL     time.Duration
+}
+
+func NewOIDCService(
+	authService *AuthService,
+	userRepo user.Repository,
+	identityRepo identity.Repository,
+	stateStore oidc.StateStore,
+	providers map[string]*oidc.Client,
+	stateTTL time.Duration,
+) *OIDCService {
+	return &OIDCService{
+		authService:  authService,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		stateStore:   stateStore,
+		providers:    providers,
+		stateTTL:     stateTTL,
+	}
+}
+
+// Providers returns the names of the configured providers
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Begin starts an authorization code flow and returns the provider URL to send the user to
+func (s *OIDCService) Begin(ctx context.Context, provider string) (string, error) {
+	client, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	stateKey, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := client.AuthCodeURL(ctx, stateKey, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.stateStore.Save(ctx, stateKey, &oidc.State{
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+	}, s.stateTTL); err != nil {
+		return "", fmt.Errorf("failed to save login state: %w", err)
+	}
+
+	return authURL, nil
+}
+
+// Complete handles the provider callback: it checks the state, exchanges the
+// code, verifies the ID token and logs in the linked user
+func (s *OIDCService) Complete(ctx context.Context, provider, code, stateKey string) (*LoginResult, error) {
+	client, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	state, err := s.stateStore.Take(ctx, stateKey)
+	if err != nil {
+		if errors.Is(err, oidc.ErrStateNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, fmt.Errorf("failed to load login state: %w", err)
+	}
+	if state.Provider != provider {
+		return nil, ErrInvalidOIDCState
+	}
+
+	token, err := client.Exchange(ctx, code, state.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// Some providers only return profile claims from the userinfo endpoint
+	if claims.Email == "" && token.AccessToken != "" {
+		userInfo, err := client.UserInfo(ctx, token.AccessToken, claims.Subject)
+		if err != nil {
+			return nil, err
+		}
+		claims.Email = userInfo.Email
+		claims.EmailVerified = userInfo.EmailVerified
+		claims.GivenName = userInfo.GivenName
+		claims.FamilyName = userInfo.FamilyName
+	}
+
+	linkedUser, err := s.resolveUser(ctx, provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if !linkedUser.IsActive {
+		return nil, ErrUserNotActive
+	}
+
+	// Providers that do not vouch for the email create unverified users, hold them to the same rule as Login
+	if s.authService.requireEmailVerification && !linkedUser.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+
+	return s.authService.completeLogin(ctx, linkedUser)
+}
+
+// resolveUser finds the user linked to the external account, linking or creating one on first login
+func (s *OIDCService) resolveUser(ctx context.Context, provider string, claims *oidc.Claims) (*user.User, error) {
+	var email *string
+	if claims.Email != "" {
+		email = &claims.Email
+	}
+
+	linked, err := s.identityRepo.GetByProviderSubject(ctx, provider, claims.Subject)
+	if err == nil {
+		if err := s.identityRepo.TouchLastLogin(ctx, linked.ID, email); err != nil {
+			return nil, err
+		}
+		return s.userRepo.GetByID(ctx, linked.UserID)
+	}
+	if !errors.Is(err, identity.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	if email == nil {
+		return nil, ErrOIDCEmailMissing
+	}
+
+	// Link the identity in the same transaction that creates or updates the
+	// user, so a failed link does not leave an account without a way to sign in
+	var linkedUser *user.User
+	if err := s.authService.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		linkedUser, err = s.linkIdentity(ctx, provider, claims)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	logging.L().
+		WithField("event", "identity_linked").
+		WithField("user_id", linkedUser.ID).
+		WithField("provider", provider).
+		Info("External identity linked to user")
+
+	return linkedUser, nil
+}
+
+// linkIdentity links the external account to the user with its email, creating the user if there is none
+func (s *OIDCService) linkIdentity(ctx context.Context, provider string, claims *oidc.Claims) (*user.User, error) {
+	existingUser, err := s.userRepo.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Only a provider-verified email proves the external account owns the local one.
+		// An unverified local account may have been registered by someone else with the
+		// victim's address, linking it would hand them an account the victim then trusts
+		if !bool(claims.EmailVerified) || !existingUser.IsEmailVerified() {
+			return nil, ErrOIDCEmailNotVerified
+		}
+	case errors.Is(err, user.ErrUserNotFound):
+		existingUser, err = s.createUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := s.identityRepo.Create(ctx, &identity.Identity{
+		UserID:      existingUser.ID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       &claims.Email,
+		LastLoginAt: &now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return existingUser, nil
+}
+
+// createUser registers a user for an external account
+// The user has no password and can only sign in through the provider until one is set with a password reset
+func (s *OIDCService) createUser(ctx context.Context, claims *oidc.Claims) (*user.User, error) {
+	newUser := &user.User{
+		Email:    claims.Email,
+		IsActive: true,
+	}
+	if claims.GivenName != "" {
+		newUser.FirstName = &claims.GivenName
+	}
+	if claims.FamilyName != "" {
+		newUser.LastName = &claims.FamilyName
+	}
+	if claims.EmailVerified {
+		now := time.Now().UTC()
+		newUser.EmailVerifiedAt = &now
+	}
+
+	if err := s.authService.createUser(ctx, newUser); err != nil {
+		return nil, err
+	}
+
+	return newUser, nil
+}
+
+
+// OIDC providers related structs
+
+type OIDCProvidersRequest struct{}
+
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// OIDC login related structs
+
+type OIDCLoginRequest struct {
+	Provider string `params:"provider" validate:"required"`
+}
+
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDC callback related structs
+
+type OIDCCallbackRequest struct {
+	Provider         string `params:"provider" validate:"required"`
+	Code             string `json:"code" query:"code" validate:"required_without=Error"`
+	State            string `json:"state" query:"state" validate:"required"`
+	Error            string `json:"error" query:"error"`
+	ErrorDescription string `json:"error_description" query:"error_description"`
+}
+
+
+// toOIDCError maps OIDC login errors to API errors
+func toOIDCError(err error) error {
+	switch {
+	case errors.Is(err, ErrUnknownProvider):
+		return appErrors.NewNotFoundError("Unknown identity provider", err)
+	case errors.Is(err, ErrInvalidOIDCState):
+		return appErrors.NewBadRequestError("Invalid or expired login state, please start again", err)
+	case errors.Is(err, oidc.ErrExchange), errors.Is(err, oidc.ErrInvalidIDToken), errors.Is(err, oidc.ErrSubjectMismatch):
+		return appErrors.NewUnauthorizedError("Identity provider login could not be verified", err)
+	case errors.Is(err, oidc.ErrDiscovery), errors.Is(err, oidc.ErrUserInfo):
+		return appErrors.NewServiceUnavailableError("Identity provider is unavailable", err)
+	case errors.Is(err, ErrOIDCEmailMissing):
+		return appErrors.NewBadRequestError("Identity provider did not share an email address", err)
+	case errors.Is(err, ErrOIDCEmailNotVerified), errors.Is(err, user.ErrUserAlreadyExists):
+		return appErrors.NewConflictError("An account with this email already exists, log in with your password to continue", err)
+	default:
+		return toLoginError(err)
+	}
+}
+
+
+// OIDC Providers Handler lists the configured identity providers
+
+type OIDCProvidersHandler struct {
+	service *OIDCService
+}
+
+func NewOIDCProvidersHandler(service *OIDCService) *OIDCProvidersHandler {
+	return &OIDCProvidersHandler{service: service}
+}
+
+func (h *OIDCProvidersHandler) Handle(ctx context.Context, req *OIDCProvidersRequest) (*OIDCProvidersResponse, error) {
+	return &OIDCProvidersResponse{
+		Providers: h.service.Providers(),
+	}, nil
+}
+
+
+// OIDC Login Handler starts a login with an identity provider
+
+type OIDCLoginHandler struct {
+	service *OIDCService
+}
+
+func NewOIDCLoginHandler(service *OIDCService) *OIDCLoginHandler {
+	return &OIDCLoginHandler{service: service}
+}
+
+func (h *OIDCLoginHandler) Handle(ctx context.Context, req *OIDCLoginRequest) (*OIDCLoginResponse, error) {
+	authURL, err := h.service.Begin(ctx, req.Provider)
+	if err != nil {
+		return nil, toOIDCError(err)
+	}
+
+	return &OIDCLoginResponse{
+		AuthorizationURL: authURL,
+	}, nil
+}
+
+
+// OIDC Callback Handler completes a login with an identity provider
+
+type OIDCCallbackHandler struct {
+	service *OIDCService
+}
+
+func NewOIDCCallbackHandler(service *OIDCService) *OIDCCallbackHandler {
+	return &OIDCCallbackHandler{service: service}
+}
+
+func (h *OIDCCallbackHandler) Handle(ctx context.Context, req *OIDCCallbackRequest) (*LoginResponse, error) {
+	if req.Error != "" {
+		return nil, appErrors.NewBadRequestError("Identity provider login failed", errors.New(req.Error)).
+			AddDetail("error", req.Error).
+			AddDetail("error_description", req.ErrorDescription)
+	}
+
+	result, err := h.service.Complete(ctx, req.Provider, req.Code, req.State)
+	if err != nil {
+		return nil, toOIDCError(err)
+	}
+
+	return toLoginResponse(result), nil
+}
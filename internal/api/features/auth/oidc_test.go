@@ -0,0 +1,541 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/identity"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/role"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/session"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc/oidctest"
+)
+
+func TestMain(m *testing.M) {
+	if _, err := logging.Init(logging.Config{Level: "error"}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// Fakes for the repositories used by the OIDC login, methods that are not
+// overridden panic through the nil embedded interface
+
+type fakeUserRepository struct {
+	user.Repository
+	mu     sync.Mutex
+	nextID int64
+	users  map[int64]*user.User
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, u *user.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return user.ErrUserAlreadyExists
+		}
+	}
+	r.nextID++
+	u.ID = r.nextID
+	r.users[u.ID] = u
+	return nil
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id int64) (*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		return u, nil
+	}
+	return nil, user.ErrUserNotFound
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, user.ErrUserNotFound
+}
+
+type fakeIdentityRepository struct {
+	identity.Repository
+	mu         sync.Mutex
+	identities []*identity.Identity
+}
+
+func (r *fakeIdentityRepository) Create(ctx context.Context, i *identity.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i.ID = int64(len(r.identities) + 1)
+	r.identities = append(r.identities, i)
+	return nil
+}
+
+func (r *fakeIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*identity.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return i, nil
+		}
+	}
+	return nil, identity.ErrIdentityNotFound
+}
+
+func (r *fakeIdentityRepository) TouchLastLogin(ctx context.Context, id int64, email *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	r.identities[id-1].LastLoginAt = &now
+	r.identities[id-1].Email = email
+	return nil
+}
+
+type fakeRoleRepository struct {
+	role.Repository
+	assigned map[int64][]string
+}
+
+func (r *fakeRoleRepository) GetUserAccess(ctx context.Context, userID int64) (*role.UserAccess, error) {
+	return &role.UserAccess{Roles: r.assigned[userID]}, nil
+}
+
+func (r *fakeRoleRepository) AssignRole(ctx context.Context, userID int64, roleName string) error {
+	r.assigned[userID] = append(r.assigned[userID], roleName)
+	return nil
+}
+
+type fakeSessionRepository struct {
+	session.Repository
+	created int
+}
+
+func (r *fakeSessionRepository) Create(ctx context.Context, s *session.Session) error {
+	r.created++
+	return nil
+}
+
+type fakeStateStore struct {
+	mu     sync.Mutex
+	states map[string]*oidc.State
+}
+
+func (s *fakeStateStore) Save(ctx context.Context, key string, state *oidc.State, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = state
+	return nil
+}
+
+func (s *fakeStateStore) Take(ctx context.Context, key string) (*oidc.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		return nil, oidc.ErrStateNotFound
+	}
+	delete(s.states, key)
+	return state, nil
+}
+
+// txConnector is a database/sql driver that only supports transactions, so
+// the transaction manager can run without a database. It counts rollbacks
+type txConnector struct {
+	rollbacks *int
+}
+
+func (c txConnector) Connect(context.Context) (driver.Conn, error) { return txConn(c), nil }
+func (c txConnector) Driver() driver.Driver                        { return nil }
+
+type txConn txConnector
+
+func (c txConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("queries are not supported")
+}
+func (c txConn) Close() error              { return nil }
+func (c txConn) Begin() (driver.Tx, error) { return c, nil }
+func (c txConn) Commit() error             { return nil }
+func (c txConn) Rollback() error           { *c.rollbacks++; return nil }
+
+// oidcTestEnv is an OIDC service signing users in at a fake provider named "test"
+type oidcTestEnv struct {
+	provider   *oidctest.Provider
+	service    *OIDCService
+	users      *fakeUserRepository
+	identities *fakeIdentityRepository
+	roles      *fakeRoleRepository
+	sessions   *fakeSessionRepository
+	rollbacks  int
+}
+
+func newOIDCTestEnv(t *testing.T, requireEmailVerification bool) *oidcTestEnv {
+	t.Helper()
+
+	env := &oidcTestEnv{
+		provider:   oidctest.NewProvider(t, "starter", "secret"),
+		users:      &fakeUserRepository{users: make(map[int64]*user.User)},
+		identities: &fakeIdentityRepository{},
+		roles:      &fakeRoleRepository{assigned: make(map[int64][]string)},
+		sessions:   &fakeSessionRepository{},
+	}
+
+	db := sqlx.NewDb(sql.OpenDB(txConnector{rollbacks: &env.rollbacks}), "postgres")
+	t.Cleanup(func() { db.Close() })
+	txManager, err := database.NewTxManager(db, &config.TransactionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := pkgJWT.NewHMACKey("test", "HS256", "test-secret-with-at-least-32-bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyRing, err := pkgJWT.NewKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtManager := pkgJWT.NewManager(keyRing, time.Minute, time.Hour)
+
+	authService := NewAuthService(env.users, env.roles, env.sessions, txManager, jwtManager,
+		nil, nil, nil, nil, requireEmailVerification)
+
+	other := oidctest.NewProvider(t, "starter", "secret")
+	env.service = NewOIDCService(
+		authService,
+		env.users,
+		env.identities,
+		&fakeStateStore{states: make(map[string]*oidc.State)},
+		map[string]*oidc.Client{
+			"test":  env.provider.Client(),
+			"other": other.Client(),
+		},
+		time.Minute,
+	)
+
+	return env
+}
+
+// begin starts a login and signs the user in at the provider, returning the
+// code and state the provider redirects back with
+func (e *oidcTestEnv) begin(t *testing.T, provider string, account oidctest.User) (code, state string) {
+	t.Helper()
+
+	authURL, err := e.service.Begin(context.Background(), provider)
+	if err != nil {
+		t.Fatalf("Begin returned error: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state = parsed.Query().Get("state")
+
+	code, err = e.provider.Authorize(authURL, account)
+	if err != nil {
+		t.Fatalf("Authorize rejected the authorization request: %v", err)
+	}
+
+	return code, state
+}
+
+func (e *oidcTestEnv) login(t *testing.T, account oidctest.User) (*LoginResult, error) {
+	t.Helper()
+	code, state := e.begin(t, "test", account)
+	return e.service.Complete(context.Background(), "test", code, state)
+}
+
+// addUser registers a local user with the email
+func (e *oidcTestEnv) addUser(t *testing.T, email string, verified bool) *user.User {
+	t.Helper()
+
+	u := &user.User{Email: email, IsActive: true}
+	if verified {
+		now := time.Now().UTC()
+		u.EmailVerifiedAt = &now
+	}
+	if err := e.users.Create(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestOIDCCompleteCreatesUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		emailVerified bool
+	}{
+		{"verified email", true},
+		{"unverified email", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOIDCTestEnv(t, false)
+
+			result, err := env.login(t, oidctest.User{
+				Subject:       "sub-1",
+				Email:         "jane@example.com",
+				EmailVerified: tt.emailVerified,
+				GivenName:     "Jane",
+				FamilyName:    "Doe",
+			})
+			if err != nil {
+				t.Fatalf("Complete returned error: %v", err)
+			}
+			if result.Tokens == nil || result.Tokens.AccessToken == "" || env.sessions.created != 1 {
+				t.Fatal("Complete did not start a session")
+			}
+
+			created := result.User
+			if created.ID == 0 || created.Email != "jane@example.com" || !created.IsActive {
+				t.Errorf("created user = %+v", created)
+			}
+			if created.IsEmailVerified() != tt.emailVerified {
+				t.Errorf("email verified = %v, want %v", created.IsEmailVerified(), tt.emailVerified)
+			}
+			if created.FirstName == nil || *created.FirstName != "Jane" || created.LastName == nil || *created.LastName != "Doe" {
+				t.Errorf("names = %v %v, want Jane Doe", created.FirstName, created.LastName)
+			}
+			if created.PasswordHash != "" {
+				t.Error("user created through a provider has a password")
+			}
+			if roles := env.roles.assigned[created.ID]; len(roles) != 1 || roles[0] != role.DefaultRole {
+				t.Errorf("roles = %v, want [%s]", roles, role.DefaultRole)
+			}
+
+			linked, err := env.identities.GetByProviderSubject(context.Background(), "test", "sub-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if linked.UserID != created.ID || linked.LastLoginAt == nil {
+				t.Errorf("identity = %+v", linked)
+			}
+		})
+	}
+}
+
+func TestOIDCCompleteLinksVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		name             string
+		localVerified    bool
+		providerVerified bool
+		wantErr          error
+	}{
+		{"both verified", true, true, nil},
+		{"local account unverified", false, true, ErrOIDCEmailNotVerified},
+		{"provider email unverified", true, false, ErrOIDCEmailNotVerified},
+		{"neither verified", false, false, ErrOIDCEmailNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOIDCTestEnv(t, false)
+			local := env.addUser(t, "jane@example.com", tt.localVerified)
+
+			result, err := env.login(t, oidctest.User{
+				Subject:       "sub-1",
+				Email:         "jane@example.com",
+				EmailVerified: tt.providerVerified,
+			})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Complete error = %v, want %v", err, tt.wantErr)
+				}
+				if _, err := env.identities.GetByProviderSubject(context.Background(), "test", "sub-1"); !errors.Is(err, identity.ErrIdentityNotFound) {
+					t.Error("a rejected login linked the identity")
+				}
+				if env.rollbacks != 1 {
+					t.Errorf("rollbacks = %d, want 1", env.rollbacks)
+				}
+				if len(env.users.users) != 1 {
+					t.Errorf("users = %d, want only the local one", len(env.users.users))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Complete returned error: %v", err)
+			}
+			if result.User.ID != local.ID {
+				t.Errorf("logged in user %d, want the local user %d", result.User.ID, local.ID)
+			}
+			linked, err := env.identities.GetByProviderSubject(context.Background(), "test", "sub-1")
+			if err != nil || linked.UserID != local.ID {
+				t.Errorf("identity = %+v, %v, want linked to user %d", linked, err, local.ID)
+			}
+		})
+	}
+}
+
+func TestOIDCCompleteUsesLinkedIdentity(t *testing.T) {
+	env := newOIDCTestEnv(t, false)
+
+	first, err := env.login(t, oidctest.User{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider account changed its email, the subject still identifies it
+	second, err := env.login(t, oidctest.User{Subject: "sub-1", Email: "jane@new.example.com"})
+	if err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if second.User.ID != first.User.ID || len(env.users.users) != 1 {
+		t.Errorf("second login signed in user %d of %d, want user %d", second.User.ID, len(env.users.users), first.User.ID)
+	}
+	linked, _ := env.identities.GetByProviderSubject(context.Background(), "test", "sub-1")
+	if linked.Email == nil || *linked.Email != "jane@new.example.com" {
+		t.Errorf("identity email = %v, want the latest email", linked.Email)
+	}
+
+	// An email matching another local user does not matter once the identity is linked
+	env.addUser(t, "taken@example.com", true)
+	third, err := env.login(t, oidctest.User{Subject: "sub-1", Email: "taken@example.com", EmailVerified: true})
+	if err != nil || third.User.ID != first.User.ID {
+		t.Errorf("third login = %v, %v, want user %d", third, err, first.User.ID)
+	}
+}
+
+func TestOIDCCompleteFetchesUserInfo(t *testing.T) {
+	env := newOIDCTestEnv(t, false)
+	env.provider.ProfileInUserInfo = true
+
+	result, err := env.login(t, oidctest.User{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true, GivenName: "Jane"})
+	if err != nil {
+		t.Fatalf("Complete returned error: %v", err)
+	}
+	if result.User.Email != "jane@example.com" || !result.User.IsEmailVerified() || result.User.FirstName == nil {
+		t.Errorf("created user = %+v", result.User)
+	}
+}
+
+func TestOIDCCompleteRejections(t *testing.T) {
+	t.Run("missing email", func(t *testing.T) {
+		env := newOIDCTestEnv(t, false)
+		if _, err := env.login(t, oidctest.User{Subject: "sub-1"}); !errors.Is(err, ErrOIDCEmailMissing) {
+			t.Errorf("Complete error = %v, want ErrOIDCEmailMissing", err)
+		}
+	})
+
+	t.Run("unverified email with verification required", func(t *testing.T) {
+		env := newOIDCTestEnv(t, true)
+		if _, err := env.login(t, oidctest.User{Subject: "sub-1", Email: "jane@example.com"}); !errors.Is(err, ErrEmailNotVerified) {
+			t.Errorf("Complete error = %v, want ErrEmailNotVerified", err)
+		}
+		if _, err := env.login(t, oidctest.User{Subject: "sub-2", Email: "john@example.com", EmailVerified: true}); err != nil {
+			t.Errorf("Complete with a verified email returned error: %v", err)
+		}
+	})
+
+	t.Run("inactive user", func(t *testing.T) {
+		env := newOIDCTestEnv(t, false)
+		account := oidctest.User{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true}
+		result, err := env.login(t, account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result.User.IsActive = false
+
+		if _, err := env.login(t, account); !errors.Is(err, ErrUserNotActive) {
+			t.Errorf("Complete error = %v, want ErrUserNotActive", err)
+		}
+	})
+}
+
+func TestOIDCCompleteChecksState(t *testing.T) {
+	account := oidctest.User{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true}
+	ctx := context.Background()
+
+	t.Run("unknown state", func(t *testing.T) {
+		env := newOIDCTestEnv(t, false)
+		code, _ := env.begin(t, "test", account)
+		if _, err := env.service.Complete(ctx, "test", code, "forged"); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Errorf("Complete error = %v, want ErrInvalidOIDCState", err)
+		}
+	})
+
+	t.Run("state used twice", func(t *testing.T) {
+		env := newOIDCTestEnv(t, false)
+		code, state := env.begin(t, "test", account)
+		if _, err := env.service.Complete(ctx, "test", code, state); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.service.Complete(ctx, "test", code, state); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Errorf("Complete error = %v, want ErrInvalidOIDCState", err)
+		}
+	})
+
+	t.Run("state of another provider", func(t *testing.T) {
+		env := newOIDCTestEnv(t, false)
+		code, _ := env.begin(t, "test", account)
+		_, otherState := env.begin(t, "other", account)
+		if _, err := env.service.Complete(ctx, "test", code, otherState); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Errorf("Complete error = %v, want ErrInvalidOIDCState", err)
+		}
+	})
+
+	t.Run("code of another login", func(t *testing.T) {
+		// The state's PKCE verifier does not match the code's challenge
+		env := newOIDCTestEnv(t, false)
+		code, _ := env.begin(t, "test", account)
+		_, state := env.begin(t, "test", account)
+		if _, err := env.service.Complete(ctx, "test", code, state); !errors.Is(err, oidc.ErrExchange) {
+			t.Errorf("Complete error = %v, want ErrExchange", err)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		env := newOIDCTestEnv(t, false)
+		if _, err := env.service.Begin(ctx, "missing"); !errors.Is(err, ErrUnknownProvider) {
+			t.Errorf("Begin error = %v, want ErrUnknownProvider", err)
+		}
+		if _, err := env.service.Complete(ctx, "missing", "code", "state"); !errors.Is(err, ErrUnknownProvider) {
+			t.Errorf("Complete error = %v, want ErrUnknownProvider", err)
+		}
+	})
+}
+
+func TestToOIDCError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{ErrUnknownProvider, http.StatusNotFound},
+		{ErrInvalidOIDCState, http.StatusBadRequest},
+		{oidc.ErrExchange, http.StatusUnauthorized},
+		{oidc.ErrInvalidIDToken, http.StatusUnauthorized},
+		{oidc.ErrDiscovery, http.StatusServiceUnavailable},
+		{ErrOIDCEmailMissing, http.StatusBadRequest},
+		{ErrOIDCEmailNotVerified, http.StatusConflict},
+		{ErrEmailNotVerified, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		var serviceErr *appErrors.ServiceError
+		if !errors.As(toOIDCError(tt.err), &serviceErr) || serviceErr.StatusCode != tt.status {
+			t.Errorf("toOIDCError(%v) = %v, want status %d", tt.err, serviceErr, tt.status)
+		}
+	}
+}
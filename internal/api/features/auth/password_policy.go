@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/passwordhistory"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
+)
+
+// PasswordPolicyService enforces the password policy on new passwords and keeps
+// the password history used to reject recently used passwords
+type PasswordPolicyService struct {
+	policy        password.Policy
+	historySize   int
+	historyRepo   passwordhistory.Repository
+	hasher        password.PasswordHasher
+	breachChecker password.BreachChecker
+}
+
+// NewPasswordPolicyService creates a new password policy service
+// historySize is the number of previous passwords that cannot be reused, 0 disables
+// the history, and breachChecker may be nil to disable the breached password check
+func NewPasswordPolicyService(
+	policy password.Policy,
+	historySize int,
+	historyRepo passwordhistory.Repository,
+	hasher password.PasswordHasher,
+	breachChecker password.BreachChecker,
+) *PasswordPolicyService {
+	return &PasswordPolicyService{
+		policy:        policy,
+		historySize:   historySize,
+		historyRepo:   historyRepo,
+		hasher:        hasher,
+		breachChecker: breachChecker,
+	}
+}
+
+// Check validates a new password of the user, returning a *password.PolicyError
+// listing every broken rule. Users that do not exist yet have an ID of 0 and no history.
+// The breach check fails open, an unavailable provider does not block the password
+func (s *PasswordPolicyService) Check(ctx context.Context, u *user.User, plainPassword string) error {
+	violations := s.policy.Check(plainPassword, u.Email)
+
+	if u.ID != 0 && s.historySize > 0 {
+		reused, err := s.isReused(ctx, u, plainPassword)
+		if err != nil {
+			return err
+		}
+		if reused {
+			violations = append(violations, password.Violation{
+				Rule:    password.RuleReused,
+				Message: "Must not be one of your recent passwords",
+			})
+		}
+	}
+
+	if s.breachChecker != nil {
+		breached, err := s.breachChecker.IsBreached(ctx, plainPassword)
+		if err != nil {
+			logging.L().WithError(err).Warn("Breached password check failed, skipping it")
+		}
+		if breached {
+			violations = append(violations, password.Violation{
+				Rule:    password.RuleBreached,
+				Message: "Has appeared in a data breach, please choose a different password",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &password.PolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// isReused reports whether the password matches the current or a recent password of the user
+func (s *PasswordPolicyService) isReused(ctx context.Context, u *user.User, plainPassword string) (bool, error) {
+	hashes, err := s.historyRepo.ListRecent(ctx, u.ID, s.historySize)
+	if err != nil {
+		return false, err
+	}
+	if u.PasswordHash != "" {
+		hashes = append(hashes, u.PasswordHash)
+	}
+
+	for _, hash := range hashes {
+		matches, err := s.hasher.Verify(plainPassword, hash)
+		if err != nil {
+			continue
+		}
+		if matches {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Record adds a newly set password hash to the user's history and forgets older ones
+func (s *PasswordPolicyService) Record(ctx context.Context, userID int64, passwordHash string) error {
+	if s.historySize <= 0 {
+		return nil
+	}
+
+	if err := s.historyRepo.Add(ctx, userID, passwordHash); err != nil {
+		return err
+	}
+
+	return s.historyRepo.Prune(ctx, userID, s.historySize)
+}
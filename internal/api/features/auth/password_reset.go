@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/usertoken"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/mailer"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+)
+
+// PasswordResetService issues and consumes single-use password reset tokens
+type PasswordResetService struct {
+	userRepo   user.Repository
+	tokenRepo  usertoken.Repository
+	txManager  *database.TxManager
+	jwtManager *pkgJWT.Manager
+	hasher     password.PasswordHasher
+	policy     *PasswordPolicyService
+	mailer     mailer.Mailer
+	tokenTTL   time.Duration
+	resetURL   string
+}
+
+func NewPasswordResetService(
+	userRepo user.Repository,
+	tokenRepo usertoken.Repository,
+	txManager *database.TxManager,
+	jwtManager *pkgJWT.Manager,
+	hasher password.PasswordHasher,
+	policy *PasswordPolicyService,
+	mailer mailer.Mailer,
+	tokenTTL time.Duration,
+	resetURL string,
+) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		txManager:  txManager,
+		jwtManager: jwtManager,
+		hasher:     hasher,
+		policy:     policy,
+		mailer:     mailer,
+		tokenTTL:   tokenTTL,
+		resetURL:   resetURL,
+	}
+}
+
+// RequestReset emails a reset link to the user with the given email
+// Unknown or inactive accounts are silently ignored so callers cannot enumerate users
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	existingUser, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !existingUser.IsActive {
+		return nil
+	}
+
+	// Only the most recently requested token stays valid
+	if err := s.tokenRepo.InvalidateForUser(ctx, existingUser.ID, usertoken.PurposePasswordReset); err != nil {
+		return err
+	}
+
+	token, hash, err := usertoken.Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.Create(ctx, &usertoken.UserToken{
+		UserID:    existingUser.ID,
+		Purpose:   usertoken.PurposePasswordReset,
+		TokenHash: hash,
+		ExpiresAt: time.Now().UTC().Add(s.tokenTTL),
+	}); err != nil {
+		return err
+	}
+
+	// Deliver in the background so response time does not reveal whether the account exists
+	msg := s.resetMessage(existingUser.Email, token)
+	go func() {
+		if err := s.mailer.Send(context.Background(), msg); err != nil {
+			logging.L().
+				WithError(err).
+				WithField("user_id", existingUser.ID).
+				Error("Failed to send password reset email")
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword consumes the token, stores the new password and logs out every session
+// The token stays valid when the new password breaks the password policy
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	resetToken, err := s.tokenRepo.GetValid(ctx, usertoken.PurposePasswordReset, usertoken.Hash(token))
+	if err != nil {
+		if errors.Is(err, usertoken.ErrTokenNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	ctx = database.WithPrimary(ctx)
+
+	existingUser, err := s.userRepo.GetByID(ctx, resetToken.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.policy.Check(ctx, existingUser, newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	existingUser.PasswordHash = hashedPassword
+	// Receiving the reset email proves ownership of the address
+	if !existingUser.IsEmailVerified() {
+		now := time.Now().UTC()
+		existingUser.EmailVerifiedAt = &now
+	}
+
+	// Consume the token, store the password and remember it in one transaction
+	// so the token is only used up when the new password was saved
+	if err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// A concurrent reset with the same token loses here
+		if _, err := s.tokenRepo.Consume(ctx, usertoken.PurposePasswordReset, resetToken.TokenHash); err != nil {
+			if errors.Is(err, usertoken.ErrTokenNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		if err := s.userRepo.Update(ctx, existingUser); err != nil {
+			return err
+		}
+
+		return s.policy.Record(ctx, existingUser.ID, hashedPassword)
+	}); err != nil {
+		return err
+	}
+
+	if err := s.jwtManager.RevokeUser(ctx, existingUser.ID); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PasswordResetService) resetMessage(email, token string) mailer.Message {
+	link := appendQuery(s.resetURL, "token", token)
+
+	return mailer.Message{
+		To:      []string{email},
+		Subject: "Reset your password",
+		TextBody: fmt.Sprintf(
+			"We received a request to reset your password.\n\n"+
+				"Open the link below to choose a new password:\n%s\n\n"+
+				"The link expires in %s. If you did not request a reset, you can ignore this email.",
+			link,
+			s.tokenTTL,
+		),
+	}
+}
+
+// appendQuery adds a query parameter to a URL, keeping any existing parameters
+func appendQuery(rawURL, key, value string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL + "?" + url.Values{key: {value}}.Encode()
+	}
+
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+
+// Forgot password related structs
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+func (r *ForgotPasswordResponse) StatusCode() int {
+	return 202
+}
+
+// Reset password related structs
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ResetPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+
+// Forgot Password Handler starts the password reset flow
+
+type ForgotPasswordHandler struct {
+	service *PasswordResetService
+}
+
+func NewForgotPasswordHandler(service *PasswordResetService) *ForgotPasswordHandler {
+	return &ForgotPasswordHandler{service: service}
+}
+
+func (h *ForgotPasswordHandler) Handle(ctx context.Context, req *ForgotPasswordRequest) (*ForgotPasswordResponse, error) {
+	if err := h.service.RequestReset(ctx, req.Email); err != nil {
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	return &ForgotPasswordResponse{
+		Message: "If an account with that email exists, a password reset link has been sent",
+	}, nil
+}
+
+
+// Reset Password Handler completes the password reset flow
+
+type ResetPasswordHandler struct {
+	service *PasswordResetService
+}
+
+func NewResetPasswordHandler(service *PasswordResetService) *ResetPasswordHandler {
+	return &ResetPasswordHandler{service: service}
+}
+
+func (h *ResetPasswordHandler) Handle(ctx context.Context, req *ResetPasswordRequest) (*ResetPasswordResponse, error) {
+	if err := h.service.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidResetToken) {
+			return nil, appErrors.NewBadRequestError("Invalid or expired password reset token", err)
+		}
+		if policyErr := password.ToServiceError("new_password", err); policyErr != nil {
+			return nil, policyErr
+		}
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	return &ResetPasswordResponse{
+		Message: "Password has been reset successfully",
+	}, nil
+}
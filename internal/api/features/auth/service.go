@@ -4,93 +4,389 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
-	"golang.org/x/crypto/bcrypt"
-
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/mfa"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/role"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/session"
 	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
 	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserNotActive      = errors.New("user is not active")
+	ErrEmailNotVerified   = errors.New("email is not verified")
 )
 
+// MFAVerifier checks the second factor of users with two-factor authentication
+type MFAVerifier interface {
+	IsEnabled(ctx context.Context, userID int64) (bool, error)
+	Verify(ctx context.Context, userID int64, code string) error
+}
+
 // Service provides authentication related operations
 type AuthService struct {
-	userRepo   user.Repository
-	jwtManager *pkgJWT.Manager
+	userRepo                 user.Repository
+	roleRepo                 role.Repository
+	sessionRepo              session.Repository
+	txManager                *database.TxManager
+	jwtManager               *pkgJWT.Manager
+	hasher                   password.PasswordHasher
+	passwordPolicy           *PasswordPolicyService
+	loginGuard               *LoginGuard
+	mfaVerifier              MFAVerifier
+	requireEmailVerification bool
 }
 
-func NewAuthService(userRepo user.Repository, jwtManager *pkgJWT.Manager) *AuthService {
+// NewAuthService creates a new auth service
+// loginGuard may be nil to disable brute-force protection on login and
+// mfaVerifier may be nil to disable two-factor authentication
+func NewAuthService(
+	userRepo user.Repository,
+	roleRepo role.Repository,
+	sessionRepo session.Repository,
+	txManager *database.TxManager,
+	jwtManager *pkgJWT.Manager,
+	hasher password.PasswordHasher,
+	passwordPolicy *PasswordPolicyService,
+	loginGuard *LoginGuard,
+	mfaVerifier MFAVerifier,
+	requireEmailVerification bool,
+) *AuthService {
 	return &AuthService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:                 userRepo,
+		roleRepo:                 roleRepo,
+		sessionRepo:              sessionRepo,
+		txManager:                txManager,
+		jwtManager:               jwtManager,
+		hasher:                   hasher,
+		passwordPolicy:           passwordPolicy,
+		loginGuard:               loginGuard,
+		mfaVerifier:              mfaVerifier,
+		requireEmailVerification: requireEmailVerification,
 	}
 }
 
+// RequiresEmailVerification reports whether users must verify their email before logging in
+func (s *AuthService) RequiresEmailVerification() bool {
+	return s.requireEmailVerification
+}
+
 // Register creates a new user account
-func (s *AuthService) Register(ctx context.Context, email, password string, firstName, lastName *string) (*user.User, error) {
+// Passwords breaking the password policy are rejected with a *password.PolicyError
+func (s *AuthService) Register(ctx context.Context, email, plainPassword string, firstName, lastName *string) (*user.User, error) {
+	// Enforce the password policy
+	if err := s.passwordPolicy.Check(ctx, &user.User{Email: email}, plainPassword); err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(plainPassword)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, err
 	}
 
 	// Create user
 	newUser := &user.User{
 		Email:        email,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		FirstName:    firstName,
 		LastName:     lastName,
 		IsActive:     true,
 	}
 
-	if err := s.userRepo.Create(ctx, newUser); err != nil {
+	// Store the user, its default role and its first password history entry together
+	if err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.createUser(ctx, newUser); err != nil {
+			return err
+		}
+		return s.passwordPolicy.Record(ctx, newUser.ID, newUser.PasswordHash)
+	}); err != nil {
 		return nil, err
 	}
 
 	return newUser, nil
 }
 
-// Login authenticates a user and returns JWT tokens
-func (s *AuthService) Login(ctx context.Context, email, password string) (*pkgJWT.TokenPair, *user.User, error) {
+// createUser stores a new user and grants the default role
+func (s *AuthService) createUser(ctx context.Context, newUser *user.User) error {
+	if err := s.userRepo.Create(ctx, newUser); err != nil {
+		return err
+	}
+
+	// Grant the default role
+	if err := s.roleRepo.AssignRole(ctx, newUser.ID, role.DefaultRole); err != nil {
+		return fmt.Errorf("failed to assign default role: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateTokens starts a new session and issues its token pair embedding the
+// user's roles and permissions. The session records the client's device and IP
+func (s *AuthService) GenerateTokens(ctx context.Context, u *user.User) (*pkgJWT.TokenPair, error) {
+	subject, err := s.subjectFor(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.jwtManager.GenerateTokenPair(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Create(ctx, s.newSession(ctx, tokens.SessionID, u.ID)); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// newSession describes a session of the user started by the current client
+func (s *AuthService) newSession(ctx context.Context, sessionID string, userID int64) *session.Session {
+	return session.New(
+		sessionID,
+		userID,
+		middlewares.GetUserAgentFromContext(ctx),
+		middlewares.GetClientIPFromContext(ctx),
+		time.Now().UTC().Add(s.jwtManager.RefreshTokenDuration()),
+	)
+}
+
+// subjectFor loads the user's current roles and permissions for token claims
+func (s *AuthService) subjectFor(ctx context.Context, u *user.User) (pkgJWT.Subject, error) {
+	access, err := s.roleRepo.GetUserAccess(ctx, u.ID)
+	if err != nil {
+		return pkgJWT.Subject{}, fmt.Errorf("failed to get user access: %w", err)
+	}
+
+	return pkgJWT.Subject{
+		UserID:      u.ID,
+		Email:       u.Email,
+		Roles:       access.Roles,
+		Permissions: access.Permissions,
+	}, nil
+}
+
+// LoginResult is the outcome of a successful first or second login step
+// Tokens is set once the user is fully authenticated, MFAToken is set instead
+// when a second factor is still required
+type LoginResult struct {
+	User     *user.User
+	Tokens   *pkgJWT.TokenPair
+	MFAToken string
+}
+
+// Login authenticates a user with email and password
+// Users with two-factor authentication get an MFA pending token to exchange
+// with VerifyMFA, everyone else gets JWT tokens right away.
+// Repeated failures lock the account and the client IP, see LoginGuard
+func (s *AuthService) Login(ctx context.Context, email, plainPassword string) (*LoginResult, error) {
+	ip := middlewares.GetClientIPFromContext(ctx)
+
+	// Reject locked accounts and clients before doing any work
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Check(ctx, email, ip); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get user by email
 	existingUser, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, user.ErrUserNotFound) {
-			return nil, nil, ErrInvalidCredentials
+			return nil, s.loginFailed(ctx, email, ip, nil, ErrInvalidCredentials)
 		}
-		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Check if user is active
 	if !existingUser.IsActive {
-		return nil, nil, ErrUserNotActive
+		return nil, ErrUserNotActive
 	}
 
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.PasswordHash), []byte(password)); err != nil {
-		return nil, nil, ErrInvalidCredentials
+	// A stored hash that cannot be verified is treated like a wrong password
+	matches, err := s.hasher.Verify(plainPassword, existingUser.PasswordHash)
+	if err != nil {
+		logging.L().
+			WithError(err).
+			WithField("user_id", existingUser.ID).
+			Error("Failed to verify password hash")
+	}
+	if !matches {
+		return nil, s.loginFailed(ctx, email, ip, &existingUser.ID, ErrInvalidCredentials)
+	}
+
+	// Upgrade hashes created with an outdated algorithm or cost while the plain password is at hand
+	if s.hasher.NeedsRehash(existingUser.PasswordHash) {
+		s.rehashPassword(ctx, existingUser, plainPassword)
+	}
+
+	if s.loginGuard != nil {
+		if err := s.loginGuard.RecordSuccess(ctx, email); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check if email is verified, only after the password so the check does not leak account state
+	if s.requireEmailVerification && !existingUser.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+
+	return s.completeLogin(ctx, existingUser)
+}
+
+// rehashPassword stores a fresh hash of the user's password
+// Failures are only logged since the login itself succeeded
+func (s *AuthService) rehashPassword(ctx context.Context, u *user.User, plainPassword string) {
+	// u may come from a lagging replica, write back the current row from the primary
+	// and leave it alone if the password changed in the meantime
+	current, err := s.userRepo.GetByID(database.WithPrimary(ctx), u.ID)
+	if err == nil && current.PasswordHash != u.PasswordHash {
+		return
+	}
+
+	var hashedPassword string
+	if err == nil {
+		hashedPassword, err = s.hasher.Hash(plainPassword)
+	}
+	if err == nil {
+		current.PasswordHash = hashedPassword
+		err = s.userRepo.Update(ctx, current)
+	}
+
+	if err != nil {
+		logging.L().
+			WithError(err).
+			WithField("user_id", u.ID).
+			Warn("Failed to upgrade password hash")
+		return
+	}
+
+	logging.L().
+		WithField("user_id", u.ID).
+		Info("Password hash upgraded")
+}
+
+// completeLogin finishes the first login step of an authenticated user,
+// asking for the second factor if the user enabled it and issuing tokens otherwise
+func (s *AuthService) completeLogin(ctx context.Context, u *user.User) (*LoginResult, error) {
+	if s.mfaVerifier != nil {
+		enabled, err := s.mfaVerifier.IsEnabled(ctx, u.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check two-factor authentication: %w", err)
+		}
+
+		if enabled {
+			mfaToken, err := s.jwtManager.GenerateMFAPendingToken(pkgJWT.Subject{
+				UserID: u.ID,
+				Email:  u.Email,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate MFA token: %w", err)
+			}
+			return &LoginResult{MFAToken: mfaToken}, nil
+		}
 	}
 
 	// Generate JWT tokens
-	tokens, err := s.jwtManager.GenerateTokenPair(existingUser.ID, existingUser.Email)
+	tokens, err := s.GenerateTokens(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+
+	return &LoginResult{User: u, Tokens: tokens}, nil
+}
+
+// VerifyMFA completes a two-step login by exchanging an MFA pending token and
+// a TOTP or recovery code for JWT tokens. The MFA token is claimed before the
+// code is checked, so it is used up by the first attempt, right or wrong.
+// Wrong codes count as failed logins, see LoginGuard
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string) (*LoginResult, error) {
+	if s.mfaVerifier == nil {
+		return nil, pkgJWT.ErrInvalidToken
+	}
+
+	claims, err := s.jwtManager.ClaimMFAPendingToken(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := middlewares.GetClientIPFromContext(ctx)
+
+	if s.loginGuard != nil {
+		if err := s.loginGuard.Check(ctx, claims.Email, ip); err != nil {
+			return nil, err
+		}
+	}
+
+	existingUser, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !existingUser.IsActive {
+		return nil, ErrUserNotActive
+	}
+
+	if err := s.mfaVerifier.Verify(ctx, existingUser.ID, code); err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			return nil, s.loginFailed(ctx, claims.Email, ip, &existingUser.ID, err)
+		}
+		return nil, fmt.Errorf("failed to verify two-factor code: %w", err)
+	}
+
+	tokens, err := s.GenerateTokens(ctx, existingUser)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate tokens: %w", err)
+		return nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	return tokens, existingUser, nil
+	return &LoginResult{User: existingUser, Tokens: tokens}, nil
 }
 
-// RefreshToken generates new tokens using a valid refresh token
+// loginFailed records a failed login and returns the error to report,
+// failure itself or an AccountLockedError if this attempt caused a lockout
+func (s *AuthService) loginFailed(ctx context.Context, email, ip string, userID *int64, failure error) error {
+	if s.loginGuard == nil {
+		return failure
+	}
+
+	if err := s.loginGuard.RecordFailure(ctx, email, ip, userID); err != nil {
+		return err
+	}
+	return failure
+}
+
+// RefreshToken exchanges a valid refresh token for a new token pair
+// Refresh tokens are single use, presenting one that has already been
+// rotated revokes the whole session and is logged as a security event.
+// Refresh tokens of revoked sessions are rejected with pkgJWT.ErrRevokedToken
 func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*pkgJWT.TokenPair, error) {
 	// Validate refresh token
-	claims, err := s.jwtManager.ValidateRefreshToken(refreshToken)
+	claims, err := s.jwtManager.ValidateRefreshToken(ctx, refreshToken)
 	if err != nil {
 		return nil, err
 	}
 
+	// Check the session has not been revoked, sessions started before session
+	// tracking existed are recorded now
+	existingSession, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+	if err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+		return nil, err
+	}
+	if existingSession != nil && (existingSession.IsRevoked() || existingSession.UserID != claims.UserID) {
+		return nil, pkgJWT.ErrRevokedToken
+	}
+
 	// Verify user still exists and is active
 	existingUser, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
@@ -104,11 +400,73 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*p
 		return nil, ErrUserNotActive
 	}
 
-	// Generate new token pair
-	tokens, err := s.jwtManager.GenerateTokenPair(existingUser.ID, existingUser.Email)
+	// Rotate the refresh token within its session, picking up role changes
+	subject, err := s.subjectFor(ctx, existingUser)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate tokens: %w", err)
+		return nil, err
+	}
+
+	tokens, err := s.jwtManager.RotateTokenPair(ctx, claims, subject)
+	if err != nil {
+		if errors.Is(err, pkgJWT.ErrTokenReused) {
+			logging.L().
+				WithField("event", "refresh_token_reuse").
+				WithField("user_id", claims.UserID).
+				WithField("session_id", claims.SessionID).
+				WithField("token_id", claims.ID).
+				Warn("Refresh token reuse detected, session revoked")
+			if err := s.revokeSession(ctx, claims.SessionID); err != nil {
+				return nil, err
+			}
+			return nil, pkgJWT.ErrTokenReused
+		}
+		return nil, fmt.Errorf("failed to rotate tokens: %w", err)
+	}
+
+	if err := s.touchSession(ctx, existingSession, claims); err != nil {
+		return nil, err
 	}
 
 	return tokens, nil
-}
\ No newline at end of file
+}
+
+// touchSession records that the session was just refreshed by the current client
+func (s *AuthService) touchSession(ctx context.Context, existingSession *session.Session, claims *pkgJWT.Claims) error {
+	refreshed := s.newSession(ctx, claims.SessionID, claims.UserID)
+	if existingSession == nil {
+		return s.sessionRepo.Create(ctx, refreshed)
+	}
+
+	return s.sessionRepo.Touch(ctx, refreshed.ID, refreshed.UserAgent, refreshed.IPAddress, refreshed.ExpiresAt)
+}
+
+// revokeSession marks a session as revoked, unknown sessions are ignored
+func (s *AuthService) revokeSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, sessionID); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// Logout revokes the session the given access token belongs to
+func (s *AuthService) Logout(ctx context.Context, claims *pkgJWT.Claims) error {
+	if err := s.jwtManager.RevokeSession(ctx, claims); err != nil {
+		return err
+	}
+
+	return s.revokeSession(ctx, claims.SessionID)
+}
+
+// LogoutAll revokes every session of the user
+func (s *AuthService) LogoutAll(ctx context.Context, userID int64) error {
+	if err := s.jwtManager.RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.RevokeForUser(ctx, userID)
+}
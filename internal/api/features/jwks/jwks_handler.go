@@ -0,0 +1,33 @@
+package jwks
+
+import (
+	"context"
+
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+)
+
+// JWKSRequest represents the JWKS request (empty)
+type JWKSRequest struct{}
+
+// JWKSResponse is the JSON Web Key Set other services use to verify our tokens
+type JWKSResponse struct {
+	Keys []pkgJWT.JWK `json:"keys"`
+}
+
+// JWKSHandler publishes the public verification keys of the JWT manager
+type JWKSHandler struct {
+	jwtManager *pkgJWT.Manager
+}
+
+// NewJWKSHandler creates a new JWKS handler
+func NewJWKSHandler(jwtManager *pkgJWT.Manager) *JWKSHandler {
+	return &JWKSHandler{jwtManager: jwtManager}
+}
+
+// Handle returns the current key set
+// Symmetric (HMAC) keys are never published, so the set is empty when signing with HS256
+func (h *JWKSHandler) Handle(ctx context.Context, req *JWKSRequest) (*JWKSResponse, error) {
+	return &JWKSResponse{
+		Keys: h.jwtManager.JWKS().Keys,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+)
+
+// Export related structs
+
+type ExportDataRequest struct{}
+
+type ExportDataResponse struct {
+	*Export
+}
+
+// Headers makes clients save the export as a file
+func (r *ExportDataResponse) Headers() map[string]string {
+	filename := fmt.Sprintf("user-%d-export-%s.json", r.User.ID, r.ExportedAt.Format("20060102T150405Z"))
+	return map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+	}
+}
+
+// Erase related structs
+
+type EraseAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type EraseAccountResponse struct {
+	Message string `json:"message"`
+}
+
+// toServiceError maps privacy service errors to API errors
+func toServiceError(err error) error {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		return appErrors.NewNotFoundError("User not found", err)
+	case errors.Is(err, ErrInvalidPassword):
+		return appErrors.NewValidationError("Password is incorrect", err).
+			AddDetail("password", map[string]string{
+				"rule":    "password",
+				"message": "Password is incorrect",
+			})
+	case errors.Is(err, ErrAPIKeyPrincipal):
+		return appErrors.NewForbiddenError("Accounts cannot be erased with an API key", err)
+	default:
+		return appErrors.NewInternalServerError(err)
+	}
+}
+
+
+// Export Data Handler returns all personal data of the current user as a JSON archive
+
+type ExportDataHandler struct {
+	service *PrivacyService
+}
+
+func NewExportDataHandler(service *PrivacyService) *ExportDataHandler {
+	return &ExportDataHandler{service: service}
+}
+
+func (h *ExportDataHandler) Handle(ctx context.Context, req *ExportDataRequest) (*ExportDataResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	export, err := h.service.Export(ctx, claims.UserID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &ExportDataResponse{Export: export}, nil
+}
+
+
+// Erase Account Handler anonymises the current user and signs them out everywhere
+
+type EraseAccountHandler struct {
+	service *PrivacyService
+}
+
+func NewEraseAccountHandler(service *PrivacyService) *EraseAccountHandler {
+	return &EraseAccountHandler{service: service}
+}
+
+func (h *EraseAccountHandler) Handle(ctx context.Context, req *EraseAccountRequest) (*EraseAccountResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	if err := h.service.Erase(ctx, claims, req.Password); err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &EraseAccountResponse{
+		Message: "Account erased successfully",
+	}, nil
+}
@@ -0,0 +1,191 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/apikey"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/identity"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/lockout"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/mfa"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/role"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/session"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
+)
+
+var (
+	ErrInvalidPassword = errors.New("current password is invalid")
+	ErrAPIKeyPrincipal = errors.New("accounts cannot be erased with an API key")
+)
+
+// Export holds all personal data stored about a user
+type Export struct {
+	ExportedAt    time.Time            `json:"exported_at"`
+	User          *user.User           `json:"user"`
+	Roles         []string             `json:"roles"`
+	Permissions   []string             `json:"permissions"`
+	Identities    []*identity.Identity `json:"identities"`
+	Sessions      []*session.Session   `json:"sessions"`
+	APIKeys       []*apikey.APIKey     `json:"api_keys"`
+	MFA           *MFAExport           `json:"mfa"`
+	LockoutEvents []*lockout.Event     `json:"lockout_events"`
+}
+
+// MFAExport describes the two-factor setup of a user without its secrets
+type MFAExport struct {
+	Enabled                bool       `json:"enabled"`
+	EnrolledAt             *time.Time `json:"enrolled_at,omitempty"`
+	ConfirmedAt            *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// PrivacyService exports and erases the personal data of users
+type PrivacyService struct {
+	userRepo     user.Repository
+	roleRepo     role.Repository
+	identityRepo identity.Repository
+	sessionRepo  session.Repository
+	apiKeyRepo   apikey.Repository
+	mfaRepo      mfa.Repository
+	lockoutRepo  lockout.Repository
+	jwtManager   *pkgJWT.Manager
+	hasher       password.PasswordHasher
+}
+
+func NewPrivacyService(
+	userRepo user.Repository,
+	roleRepo role.Repository,
+	identityRepo identity.Repository,
+	sessionRepo session.Repository,
+	apiKeyRepo apikey.Repository,
+	mfaRepo mfa.Repository,
+	lockoutRepo lockout.Repository,
+	jwtManager *pkgJWT.Manager,
+	hasher password.PasswordHasher,
+) *PrivacyService {
+	return &PrivacyService{
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+		identityRepo: identityRepo,
+		sessionRepo:  sessionRepo,
+		apiKeyRepo:   apiKeyRepo,
+		mfaRepo:      mfaRepo,
+		lockoutRepo:  lockoutRepo,
+		jwtManager:   jwtManager,
+		hasher:       hasher,
+	}
+}
+
+// Export collects the personal data of a user
+// Secrets such as password hashes, API key hashes and TOTP secrets are left out
+func (s *PrivacyService) Export(ctx context.Context, userID int64) (*Export, error) {
+	existingUser, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.roleRepo.GetUserAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := s.apiKeyRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaExport, err := s.exportMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutEvents, err := s.exportLockoutEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Export{
+		ExportedAt:    time.Now().UTC(),
+		User:          existingUser,
+		Roles:         access.Roles,
+		Permissions:   access.Permissions,
+		Identities:    identities,
+		Sessions:      sessions,
+		APIKeys:       apiKeys,
+		MFA:           mfaExport,
+		LockoutEvents: lockoutEvents,
+	}, nil
+}
+
+func (s *PrivacyService) exportMFA(ctx context.Context, userID int64) (*MFAExport, error) {
+	totp, err := s.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mfa.ErrNotEnrolled) {
+			return &MFAExport{}, nil
+		}
+		return nil, err
+	}
+
+	remaining, err := s.mfaRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAExport{
+		Enabled:                totp.IsEnabled(),
+		EnrolledAt:             &totp.CreatedAt,
+		ConfirmedAt:            totp.ConfirmedAt,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+func (s *PrivacyService) exportLockoutEvents(ctx context.Context, userID int64) ([]*lockout.Event, error) {
+	return s.lockoutRepo.ListByUser(ctx, userID)
+}
+
+// Erase anonymises the calling user, removes their personal data and revokes all of their tokens
+// The current password is required unless the account has none, e.g. it signs in through OIDC only
+func (s *PrivacyService) Erase(ctx context.Context, claims *pkgJWT.Claims, currentPassword string) error {
+	if claims.TokenType == pkgJWT.APIKeyToken {
+		return ErrAPIKeyPrincipal
+	}
+
+	existingUser, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	if existingUser.PasswordHash != "" {
+		matches, err := s.hasher.Verify(currentPassword, existingUser.PasswordHash)
+		if err != nil {
+			return fmt.Errorf("failed to verify password: %w", err)
+		}
+		if !matches {
+			return ErrInvalidPassword
+		}
+	}
+
+	if err := s.userRepo.Erase(ctx, existingUser.ID); err != nil {
+		return err
+	}
+
+	if err := s.jwtManager.RevokeUser(ctx, existingUser.ID); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
@@ -2,10 +2,13 @@ package profile
 
 import (
 	"context"
+	"errors"
 
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
 	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
 	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
 	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
 )
 
 // GetProfileRequest represents the profile request
@@ -43,20 +46,117 @@ func (h *GetProfileHandler) Handle(ctx context.Context, req *GetProfileRequest)
 	}
 
 	// Optionally, you can fetch full user details from database
-	user, err := h.profileService.GetUserByID(ctx, claims.UserID)
+	currentUser, err := h.profileService.GetUserByID(ctx, claims.UserID)
 	if err != nil {
 		return nil, appErrors.NewInternalServerError(err)
 	}
 
 	// Return profile information
 	return &GetProfileResponse{
-		UserID: user.ID,
-		Email:  user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
+		UserID: currentUser.ID,
+		Email:  currentUser.Email,
+		FirstName: currentUser.FirstName,
+		LastName:  currentUser.LastName,
 	}, nil
 }
 
+// UpdateProfileRequest represents the editable profile fields
+// Omitted fields are left unchanged
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name,omitempty" validate:"omitempty,max=100"`
+	LastName  *string `json:"last_name,omitempty" validate:"omitempty,max=100"`
+}
+
+// UpdateProfileHandler handles editing the authenticated user's profile
+type UpdateProfileHandler struct {
+	profileService *ProfileService
+}
+
+// NewUpdateProfileHandler creates a new update profile handler
+func NewUpdateProfileHandler(profileService *ProfileService) *UpdateProfileHandler {
+	return &UpdateProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// Handle processes the update profile request
+func (h *UpdateProfileHandler) Handle(ctx context.Context, req *UpdateProfileRequest) (*GetProfileResponse, error) {
+	claims, err := ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currentUser, err := h.profileService.UpdateProfile(ctx, claims.UserID, req.FirstName, req.LastName)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &GetProfileResponse{
+		UserID:    currentUser.ID,
+		Email:     currentUser.Email,
+		FirstName: currentUser.FirstName,
+		LastName:  currentUser.LastName,
+	}, nil
+}
+
+// ChangePasswordRequest represents the change password request
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,nefield=CurrentPassword"`
+}
+
+// ChangePasswordResponse represents the change password response
+type ChangePasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// ChangePasswordHandler handles changing the authenticated user's password
+type ChangePasswordHandler struct {
+	profileService *ProfileService
+}
+
+// NewChangePasswordHandler creates a new change password handler
+func NewChangePasswordHandler(profileService *ProfileService) *ChangePasswordHandler {
+	return &ChangePasswordHandler{
+		profileService: profileService,
+	}
+}
+
+// Handle verifies the current password and stores the new one
+// Every other session of the user is logged out, the current one stays valid
+func (h *ChangePasswordHandler) Handle(ctx context.Context, req *ChangePasswordRequest) (*ChangePasswordResponse, error) {
+	claims, err := ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.profileService.ChangePassword(ctx, claims, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, ErrInvalidPassword) {
+			return nil, appErrors.NewValidationError("Current password is incorrect", err).
+				AddDetail("current_password", map[string]string{
+					"rule":    "password",
+					"message": "Current password is incorrect",
+				})
+		}
+		if policyErr := password.ToServiceError("new_password", err); policyErr != nil {
+			return nil, policyErr
+		}
+		return nil, toServiceError(err)
+	}
+
+	return &ChangePasswordResponse{
+		Message: "Password changed successfully",
+	}, nil
+}
+
+// toServiceError maps profile service errors to API errors
+func toServiceError(err error) error {
+	if errors.Is(err, user.ErrUserNotFound) {
+		return appErrors.NewNotFoundError("User not found", err)
+	}
+	return appErrors.NewInternalServerError(err)
+}
+
 // ExtractUserClaims is a helper function to extract user claims from context
 // This can be used in any handler that needs to access the authenticated user
 // It's completely framework-agnostic
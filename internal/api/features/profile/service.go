@@ -2,21 +2,111 @@ package profile
 
 import (
 	"context"	
+	"errors"
+	"fmt"
+
 	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
+)
+
+var (
+	ErrInvalidPassword = errors.New("current password is invalid")
 )
 
+// PasswordPolicy validates new passwords and remembers them for the password history
+type PasswordPolicy interface {
+	Check(ctx context.Context, u *user.User, plainPassword string) error
+	Record(ctx context.Context, userID int64, passwordHash string) error
+}
+
 // ProfileService provides profile related operations
 
 type ProfileService struct {
-	userRepo user.Repository
+	userRepo   user.Repository
+	jwtManager *pkgJWT.Manager
+	hasher     password.PasswordHasher
+	policy     PasswordPolicy
 }
 
-func NewProfileService(userRepo user.Repository) *ProfileService{
+func NewProfileService(userRepo user.Repository, jwtManager *pkgJWT.Manager, hasher password.PasswordHasher, policy PasswordPolicy) *ProfileService{
 	return &ProfileService{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		jwtManager: jwtManager,
+		hasher:     hasher,
+		policy:     policy,
 	}
 }
 
 func (s *ProfileService) GetUserByID(ctx context.Context, userID int64) (*user.User, error) {
 	return s.userRepo.GetByID(ctx, userID)
-}
\ No newline at end of file
+}
+
+// UpdateProfile changes the non-nil name fields of the user
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID int64, firstName, lastName *string) (*user.User, error) {
+	ctx = database.WithPrimary(ctx)
+
+	existingUser, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if firstName != nil {
+		existingUser.FirstName = firstName
+	}
+	if lastName != nil {
+		existingUser.LastName = lastName
+	}
+
+	if err := s.userRepo.Update(ctx, existingUser); err != nil {
+		return nil, err
+	}
+
+	return existingUser, nil
+}
+
+// ChangePassword verifies the current password, stores the new one and
+// revokes every other session of the user; the calling session stays valid.
+// New passwords breaking the password policy are rejected with a *password.PolicyError
+func (s *ProfileService) ChangePassword(ctx context.Context, claims *pkgJWT.Claims, currentPassword, newPassword string) error {
+	// A replica may still hold the hash from before a password change that just happened
+	ctx = database.WithPrimary(ctx)
+
+	existingUser, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	matches, err := s.hasher.Verify(currentPassword, existingUser.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !matches {
+		return ErrInvalidPassword
+	}
+
+	if err := s.policy.Check(ctx, existingUser, newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	existingUser.PasswordHash = hashedPassword
+	if err := s.userRepo.Update(ctx, existingUser); err != nil {
+		return err
+	}
+
+	if err := s.policy.Record(ctx, existingUser.ID, hashedPassword); err != nil {
+		return err
+	}
+
+	if err := s.jwtManager.RevokeOtherSessions(ctx, claims); err != nil {
+		return fmt.Errorf("failed to revoke other sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/session"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+)
+
+// List related structs
+
+type ListSessionsRequest struct{}
+
+type ListSessionsResponse struct {
+	Sessions []*SessionResponse `json:"sessions"`
+}
+
+// Revoke related structs
+
+type RevokeSessionRequest struct {
+	ID string `params:"id" validate:"required,uuid"`
+}
+
+type RevokeSessionResponse struct {
+	Message string `json:"message"`
+}
+
+// Session related structs
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// toSessionResponse converts a session entity to response format
+func toSessionResponse(s *session.Session, currentSessionID string) *SessionResponse {
+	return &SessionResponse{
+		ID:         s.ID,
+		Device:     s.Device,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		Current:    s.ID == currentSessionID,
+		CreatedAt:  s.CreatedAt,
+		LastUsedAt: s.LastUsedAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
+
+
+// List Sessions Handler returns the devices the current user is logged in on
+
+type ListSessionsHandler struct {
+	service *SessionService
+}
+
+func NewListSessionsHandler(service *SessionService) *ListSessionsHandler {
+	return &ListSessionsHandler{service: service}
+}
+
+func (h *ListSessionsHandler) Handle(ctx context.Context, req *ListSessionsRequest) (*ListSessionsResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	sessions, err := h.service.List(ctx, claims.UserID)
+	if err != nil {
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	responses := make([]*SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		responses = append(responses, toSessionResponse(s, claims.SessionID))
+	}
+
+	return &ListSessionsResponse{
+		Sessions: responses,
+	}, nil
+}
+
+
+// Revoke Session Handler signs the current user out of one device
+
+type RevokeSessionHandler struct {
+	service *SessionService
+}
+
+func NewRevokeSessionHandler(service *SessionService) *RevokeSessionHandler {
+	return &RevokeSessionHandler{service: service}
+}
+
+func (h *RevokeSessionHandler) Handle(ctx context.Context, req *RevokeSessionRequest) (*RevokeSessionResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	if err := h.service.Revoke(ctx, claims.UserID, req.ID); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			return nil, appErrors.NewNotFoundError("Session not found", err)
+		}
+		return nil, appErrors.NewInternalServerError(err)
+	}
+
+	return &RevokeSessionResponse{
+		Message: "Session revoked successfully",
+	}, nil
+}
@@ -0,0 +1,70 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/session"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+)
+
+// SessionService lists and revokes the logged in devices of a user
+type SessionService struct {
+	sessionRepo session.Repository
+	jwtManager  *pkgJWT.Manager
+}
+
+func NewSessionService(sessionRepo session.Repository, jwtManager *pkgJWT.Manager) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		jwtManager:  jwtManager,
+	}
+}
+
+// List returns the active sessions of a user
+// Sessions revoked through the token denylist, e.g. by a password change,
+// are marked as revoked here and left out
+func (s *SessionService) List(ctx context.Context, userID int64) ([]*session.Session, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]*session.Session, 0, len(sessions))
+	for _, existingSession := range sessions {
+		revoked, err := s.jwtManager.IsSessionRevoked(ctx, existingSession.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !revoked {
+			active = append(active, existingSession)
+			continue
+		}
+
+		if err := s.sessionRepo.Revoke(ctx, existingSession.ID); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			return nil, err
+		}
+	}
+
+	return active, nil
+}
+
+// Revoke signs a user out of one of their sessions
+// Sessions of other users are reported as not found
+func (s *SessionService) Revoke(ctx context.Context, userID int64, sessionID string) error {
+	existingSession, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if existingSession.UserID != userID {
+		return session.ErrSessionNotFound
+	}
+
+	if err := s.jwtManager.RevokeSessionByID(ctx, sessionID); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.Revoke(ctx, sessionID)
+}
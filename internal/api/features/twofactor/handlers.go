@@ -0,0 +1,196 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/mfa"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+)
+
+// Status related structs
+
+type StatusRequest struct{}
+
+type StatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// Enroll related structs
+
+type EnrollRequest struct{}
+
+type EnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+func (r *EnrollResponse) StatusCode() int {
+	return 201
+}
+
+// Code related structs
+
+type CodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type DisableResponse struct {
+	Message string `json:"message"`
+}
+
+// toServiceError maps two-factor service errors to API errors
+func toServiceError(err error) error {
+	switch {
+	case errors.Is(err, mfa.ErrInvalidCode):
+		return appErrors.NewValidationError("Invalid two-factor authentication code", err)
+	case errors.Is(err, mfa.ErrNotEnrolled):
+		return appErrors.NewBadRequestError("Two-factor authentication is not enrolled", err)
+	case errors.Is(err, mfa.ErrAlreadyEnabled):
+		return appErrors.NewConflictError("Two-factor authentication is already enabled", err)
+	default:
+		return appErrors.NewInternalServerError(err)
+	}
+}
+
+
+// Status Handler returns the two-factor authentication state of the current user
+
+type StatusHandler struct {
+	service *TwoFactorService
+}
+
+func NewStatusHandler(service *TwoFactorService) *StatusHandler {
+	return &StatusHandler{service: service}
+}
+
+func (h *StatusHandler) Handle(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	status, err := h.service.Status(ctx, claims.UserID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &StatusResponse{
+		Enabled:                status.Enabled,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	}, nil
+}
+
+
+// Enroll Handler starts TOTP enrollment for the current user
+
+type EnrollHandler struct {
+	service *TwoFactorService
+}
+
+func NewEnrollHandler(service *TwoFactorService) *EnrollHandler {
+	return &EnrollHandler{service: service}
+}
+
+func (h *EnrollHandler) Handle(ctx context.Context, req *EnrollRequest) (*EnrollResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	enrollment, err := h.service.Enroll(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &EnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	}, nil
+}
+
+
+// Confirm Handler enables TOTP with a first code and returns the recovery codes
+
+type ConfirmHandler struct {
+	service *TwoFactorService
+}
+
+func NewConfirmHandler(service *TwoFactorService) *ConfirmHandler {
+	return &ConfirmHandler{service: service}
+}
+
+func (h *ConfirmHandler) Handle(ctx context.Context, req *CodeRequest) (*RecoveryCodesResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	codes, err := h.service.Confirm(ctx, claims.UserID, req.Code)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+
+// Disable Handler turns off TOTP for the current user
+
+type DisableHandler struct {
+	service *TwoFactorService
+}
+
+func NewDisableHandler(service *TwoFactorService) *DisableHandler {
+	return &DisableHandler{service: service}
+}
+
+func (h *DisableHandler) Handle(ctx context.Context, req *CodeRequest) (*DisableResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	if err := h.service.Disable(ctx, claims.UserID, req.Code); err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &DisableResponse{
+		Message: "Two-factor authentication has been disabled",
+	}, nil
+}
+
+
+// Regenerate Recovery Codes Handler replaces the recovery codes of the current user
+
+type RegenerateRecoveryCodesHandler struct {
+	service *TwoFactorService
+}
+
+func NewRegenerateRecoveryCodesHandler(service *TwoFactorService) *RegenerateRecoveryCodesHandler {
+	return &RegenerateRecoveryCodesHandler{service: service}
+}
+
+func (h *RegenerateRecoveryCodesHandler) Handle(ctx context.Context, req *CodeRequest) (*RecoveryCodesResponse, error) {
+	claims, ok := middlewares.GetUserFromContext(ctx)
+	if !ok {
+		return nil, appErrors.NewUnauthorizedError("User not authenticated", nil)
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(ctx, claims.UserID, req.Code)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return &RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	}, nil
+}
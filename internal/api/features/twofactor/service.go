@@ -0,0 +1,198 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/mfa"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/secretbox"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/totp"
+)
+
+// Enrollment holds what an authenticator app needs to be set up
+type Enrollment struct {
+	Secret string
+	URI    string
+}
+
+// Status describes the two-factor authentication state of a user
+type Status struct {
+	Enabled                bool
+	RecoveryCodesRemaining int
+}
+
+// TwoFactorService manages TOTP enrollment and verifies second factors
+type TwoFactorService struct {
+	mfaRepo mfa.Repository
+	box     *secretbox.Box
+	issuer  string
+}
+
+func NewTwoFactorService(mfaRepo mfa.Repository, box *secretbox.Box, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		mfaRepo: mfaRepo,
+		box:     box,
+		issuer:  issuer,
+	}
+}
+
+// Status returns whether TOTP is enabled and how many recovery codes are left
+func (s *TwoFactorService) Status(ctx context.Context, userID int64) (*Status, error) {
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Enabled: enabled}
+	if !enabled {
+		return status, nil
+	}
+
+	status.RecoveryCodesRemaining, err = s.mfaRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// IsEnabled reports whether the user has a confirmed TOTP enrollment
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID int64) (bool, error) {
+	enrollment, err := s.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mfa.ErrNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return enrollment.IsEnabled(), nil
+}
+
+// Enroll generates a new TOTP secret for the user
+// The enrollment stays pending until confirmed with a first code
+func (s *TwoFactorService) Enroll(ctx context.Context, userID int64, email string) (*Enrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.box.Seal([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	if err := s.mfaRepo.SaveTOTP(ctx, &mfa.TOTP{
+		UserID:          userID,
+		SecretEncrypted: sealed,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &Enrollment{
+		Secret: secret,
+		URI:    totp.URI(secret, s.issuer, email),
+	}, nil
+}
+
+// Confirm enables the pending enrollment if code is valid and returns a fresh set of recovery codes
+func (s *TwoFactorService) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	enrollment, err := s.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.IsEnabled() {
+		return nil, mfa.ErrAlreadyEnabled
+	}
+
+	step, ok, err := s.validateTOTP(enrollment, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, mfa.ErrInvalidCode
+	}
+
+	if err := s.mfaRepo.ConfirmTOTP(ctx, userID, step); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// Disable removes the TOTP enrollment and recovery codes after verifying a code
+func (s *TwoFactorService) Disable(ctx context.Context, userID int64, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	return s.mfaRepo.DeleteTOTP(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after verifying a code
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// Verify checks a TOTP code or a recovery code of a user with TOTP enabled
+// TOTP codes cannot be replayed and recovery codes can only be used once
+func (s *TwoFactorService) Verify(ctx context.Context, userID int64, code string) error {
+	enrollment, err := s.mfaRepo.GetTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enrollment.IsEnabled() {
+		return mfa.ErrNotEnrolled
+	}
+
+	step, ok, err := s.validateTOTP(enrollment, code)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := s.mfaRepo.UseTOTPStep(ctx, userID, step); err != nil {
+			if errors.Is(err, mfa.ErrStepAlreadyUsed) {
+				return mfa.ErrInvalidCode
+			}
+			return err
+		}
+		return nil
+	}
+
+	if err := s.mfaRepo.ConsumeRecoveryCode(ctx, userID, mfa.HashRecoveryCode(code)); err != nil {
+		if errors.Is(err, mfa.ErrRecoveryCodeNotFound) {
+			return mfa.ErrInvalidCode
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *TwoFactorService) validateTOTP(enrollment *mfa.TOTP, code string) (int64, bool, error) {
+	secret, err := s.box.Open(enrollment.SecretEncrypted)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+
+	return totp.Validate(string(secret), code, time.Now())
+}
+
+func (s *TwoFactorService) issueRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes, hashes, err := mfa.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
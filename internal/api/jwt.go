@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	infraredis "github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/redis"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+)
+
+// NewJWTManager creates the JWT manager from configuration with Redis backed
+// token revocation and refresh token rotation
+func NewJWTManager(cfg *config.JWTConfig, redisClient *redis.Client) (*pkgJWT.Manager, error) {
+	keyRing, err := LoadKeyRing(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return pkgJWT.NewManager(
+		keyRing,
+		cfg.AccessTokenDuration,
+		cfg.RefreshTokenDuration,
+		pkgJWT.WithDenylist(infraredis.NewTokenDenylist(redisClient)),
+		pkgJWT.WithRefreshTokenStore(infraredis.NewRefreshTokenStore(redisClient)),
+		pkgJWT.WithMFATokenDuration(cfg.MFATokenDuration),
+	), nil
+}
+
+// LoadKeyRing builds the JWT key ring from configuration
+// Keys found in the key directory and the configured verification keys are
+// accepted for verification, the key named KeyID signs new tokens
+func LoadKeyRing(cfg *config.JWTConfig) (*pkgJWT.KeyRing, error) {
+	var keys []*pkgJWT.SigningKey
+
+	if cfg.KeyDir != "" {
+		dirKeys, err := pkgJWT.LoadKeyDir(cfg.KeyDir)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, dirKeys...)
+	}
+
+	for _, keyCfg := range cfg.VerificationKeys {
+		key, err := loadKey(keyCfg.KeyID, keyCfg.Algorithm, keyCfg.Secret, "", keyCfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT verification key %s: %w", keyCfg.KeyID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	// Prefer a key directory entry matching KeyID over the inline key settings
+	var current *pkgJWT.SigningKey
+	for _, key := range keys {
+		if cfg.KeyID != "" && key.ID() == cfg.KeyID && key.CanSign() {
+			current = key
+			break
+		}
+	}
+
+	if current == nil {
+		key, err := loadKey(cfg.KeyID, cfg.Algorithm, cfg.Secret, cfg.PrivateKeyFile, cfg.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWT signing key: %w", err)
+		}
+		current = key
+	}
+
+	return pkgJWT.NewKeyRing(current, keys...)
+}
+
+// loadKey builds an HMAC key from the secret or an asymmetric key from PEM files
+func loadKey(keyID, algorithm, secret, privateKeyFile, publicKeyFile string) (*pkgJWT.SigningKey, error) {
+	if algorithm == "" || strings.HasPrefix(strings.ToUpper(algorithm), "HS") {
+		return pkgJWT.NewHMACKey(keyID, algorithm, secret)
+	}
+	return pkgJWT.LoadKeyFiles(keyID, algorithm, privateKeyFile, publicKeyFile)
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/mailer"
+)
+
+// NewMailer creates the mailer for the configured driver
+func NewMailer(cfg *config.MailConfig) (mailer.Mailer, error) {
+	return mailer.New(mailer.Config{
+		Driver: cfg.Driver,
+		From:   cfg.From,
+		SMTP: mailer.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			Timeout:  cfg.SMTP.Timeout,
+		},
+	})
+}
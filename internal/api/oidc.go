@@ -0,0 +1,27 @@
+package api
+
+import (
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc"
+)
+
+// NewOIDCClients creates an OpenID Connect client for every enabled provider
+func NewOIDCClients(cfg *config.OIDCConfig) map[string]*oidc.Client {
+	clients := make(map[string]*oidc.Client, len(cfg.Providers))
+
+	for name, provider := range cfg.Providers {
+		if provider.ClientID == "" {
+			continue
+		}
+
+		clients[name] = oidc.NewClient(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		})
+	}
+
+	return clients
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
+)
+
+// NewPasswordHasher creates the password hasher for the configured algorithm
+func NewPasswordHasher(cfg *config.PasswordHashingConfig) (password.PasswordHasher, error) {
+	return password.New(password.Config{
+		Algorithm:  cfg.Algorithm,
+		BcryptCost: cfg.BcryptCost,
+		Argon2id: password.Argon2idParams{
+			Memory:      cfg.Argon2id.Memory,
+			Iterations:  cfg.Argon2id.Iterations,
+			Parallelism: cfg.Argon2id.Parallelism,
+			SaltLength:  cfg.Argon2id.SaltLength,
+			KeyLength:   cfg.Argon2id.KeyLength,
+		},
+	})
+}
+
+// NewPasswordPolicy creates the password policy from configuration
+func NewPasswordPolicy(cfg *config.PasswordPolicyConfig) password.Policy {
+	return password.Policy{
+		MinLength:        cfg.MinLength,
+		MaxLength:        cfg.MaxLength,
+		RequireUppercase: cfg.RequireUppercase,
+		RequireLowercase: cfg.RequireLowercase,
+		RequireDigit:     cfg.RequireDigit,
+		RequireSymbol:    cfg.RequireSymbol,
+		DisallowEmail:    cfg.DisallowEmail,
+	}
+}
+
+// NewBreachChecker creates the breached password checker for the configured provider
+// It returns nil when the check is disabled
+func NewBreachChecker(cfg *config.BreachCheckConfig) (password.BreachChecker, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case password.BreachProviderHIBP:
+		return password.NewHIBPChecker(cfg.HIBPURL, cfg.Timeout), nil
+	case password.BreachProviderFile:
+		checker, err := password.NewFileChecker(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		return checker, nil
+	default:
+		return nil, fmt.Errorf("unknown breach check provider: %s", cfg.Provider)
+	}
+}
@@ -3,46 +3,193 @@ package api
 import (
 	"time"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/admin"
+	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/apikeys"
 	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/auth"
 	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/circuit_breaker_example"
 	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/healthcheck"
+	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/jwks"
+	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/privacy"
 	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/profile"
+	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/sessions"
+	"github.com/ozaanmetin/go-microservice-starter/internal/api/features/twofactor"
 	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/apikey"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/identity"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/lockout"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/mfa"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/passwordhistory"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/role"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/session"
 	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/usertoken"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
 	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
 	infrahttp "github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http"
 	infraredis "github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/redis"
 	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/mailer"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/password"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/secretbox"
 )
 
+// Dependencies holds the infrastructure shared by the api features
+type Dependencies struct {
+	DB             *database.Router
+	TxManager      *database.TxManager
+	Redis          *redis.Client
+	JWTManager     *pkgJWT.Manager
+	Mailer         mailer.Mailer
+	PasswordHasher password.PasswordHasher
+	BreachChecker  password.BreachChecker
+	SecretBox      *secretbox.Box
+}
+
 // NewRouteSetup creates a route setup function with the given dependencies
 // This returns a function that can be passed to infrahttp.NewServer
-func NewRouteSetup(cfg *config.Config, db *sqlx.DB) infrahttp.RouteSetupFunc {
+func NewRouteSetup(cfg *config.Config, deps Dependencies) infrahttp.RouteSetupFunc {
 	return func(s *infrahttp.Server) {
-		// Initialize JWT Manager
-		jwtManager := pkgJWT.NewManager(
-			cfg.JWT.Secret,
-			cfg.JWT.AccessTokenDuration,
-			cfg.JWT.RefreshTokenDuration,
-		)
+		db := deps.DB
+		jwtManager := deps.JWTManager
 
 		// Initialize repositories
 		userRepo := user.NewRepository(db)
+		if cfg.Redis.UserCache.Enabled {
+			userRepo = infraredis.NewCachedUserRepository(
+				userRepo,
+				deps.Redis,
+				cfg.Redis.UserCache.TTL,
+				cfg.Redis.UserCache.NegativeTTL,
+			)
+		}
+		roleRepo := role.NewRepository(db)
+		userTokenRepo := usertoken.NewRepository(db)
+		lockoutEventRepo := lockout.NewRepository(db)
+		mfaRepo := mfa.NewRepository(db)
+		identityRepo := identity.NewRepository(db)
+		apiKeyRepo := apikey.NewRepository(db)
+		sessionRepo := session.NewRepository(db)
+		passwordHistoryRepo := passwordhistory.NewRepository(db)
+
+		// Brute-force protection for login, disabled when the guard is nil
+		var loginGuard *auth.LoginGuard
+		if cfg.Auth.Lockout.Enabled {
+			loginGuard = auth.NewLoginGuard(
+				infraredis.NewLoginAttemptTracker(deps.Redis),
+				lockoutEventRepo,
+				lockoutPolicy(&cfg.Auth.Lockout, cfg.Auth.Lockout.AccountMaxAttempts),
+				lockoutPolicy(&cfg.Auth.Lockout, cfg.Auth.Lockout.IPMaxAttempts),
+			)
+		}
 
 		// Initialize services
-		authService := auth.NewAuthService(userRepo, jwtManager)
-		profileService := profile.NewProfileService(userRepo)
+		passwordPolicyService := auth.NewPasswordPolicyService(
+			NewPasswordPolicy(&cfg.Auth.PasswordPolicy),
+			cfg.Auth.PasswordPolicy.History,
+			passwordHistoryRepo,
+			deps.PasswordHasher,
+			deps.BreachChecker,
+		)
+		twoFactorService := twofactor.NewTwoFactorService(mfaRepo, deps.SecretBox, cfg.Auth.MFA.Issuer)
+		authService := auth.NewAuthService(
+			userRepo,
+			roleRepo,
+			sessionRepo,
+			deps.TxManager,
+			jwtManager,
+			deps.PasswordHasher,
+			passwordPolicyService,
+			loginGuard,
+			twoFactorService,
+			cfg.Auth.RequireEmailVerification,
+		)
+		oidcService := auth.NewOIDCService(
+			authService,
+			userRepo,
+			identityRepo,
+			infraredis.NewOIDCStateStore(deps.Redis),
+			NewOIDCClients(&cfg.Auth.OIDC),
+			cfg.Auth.OIDC.StateTTL,
+		)
+		profileService := profile.NewProfileService(userRepo, jwtManager, deps.PasswordHasher, passwordPolicyService)
+		sessionService := sessions.NewSessionService(sessionRepo, jwtManager)
+		privacyService := privacy.NewPrivacyService(
+			userRepo,
+			roleRepo,
+			identityRepo,
+			sessionRepo,
+			apiKeyRepo,
+			mfaRepo,
+			lockoutEventRepo,
+			jwtManager,
+			deps.PasswordHasher,
+		)
+		adminUserService := admin.NewUserService(userRepo, userTokenRepo, jwtManager)
+		adminLockoutService := admin.NewLockoutService(lockoutEventRepo)
+		apiKeyService := apikeys.NewAPIKeyService(apiKeyRepo, userRepo, roleRepo)
+		passwordResetService := auth.NewPasswordResetService(
+			userRepo,
+			userTokenRepo,
+			deps.TxManager,
+			jwtManager,
+			deps.PasswordHasher,
+			passwordPolicyService,
+			deps.Mailer,
+			cfg.Auth.PasswordResetTokenTTL,
+			cfg.Auth.PasswordResetURL,
+		)
+		emailVerificationService := auth.NewEmailVerificationService(
+			userRepo,
+			userTokenRepo,
+			deps.Mailer,
+			cfg.Auth.EmailVerificationTokenTTL,
+			cfg.Auth.EmailVerificationURL,
+		)
 
 		// Initialize handlers
 		refreshTokenHandler := auth.NewRefreshTokenHandler(authService)
 		loginHandler := auth.NewLoginHandler(authService)
-		registerHandler := auth.NewRegisterHandler(authService)
+		verifyMFAHandler := auth.NewVerifyMFAHandler(authService)
+		oidcProvidersHandler := auth.NewOIDCProvidersHandler(oidcService)
+		oidcLoginHandler := auth.NewOIDCLoginHandler(oidcService)
+		oidcCallbackHandler := auth.NewOIDCCallbackHandler(oidcService)
+		registerHandler := auth.NewRegisterHandler(authService, emailVerificationService)
+		logoutHandler := auth.NewLogoutHandler(authService)
+		logoutAllHandler := auth.NewLogoutAllHandler(authService)
+		forgotPasswordHandler := auth.NewForgotPasswordHandler(passwordResetService)
+		resetPasswordHandler := auth.NewResetPasswordHandler(passwordResetService)
+		verifyEmailHandler := auth.NewVerifyEmailHandler(emailVerificationService)
+		resendVerificationHandler := auth.NewResendVerificationHandler(emailVerificationService)
 		profileHandler := profile.NewGetProfileHandler(profileService)
+		updateProfileHandler := profile.NewUpdateProfileHandler(profileService)
+		changePasswordHandler := profile.NewChangePasswordHandler(profileService)
+		exportDataHandler := privacy.NewExportDataHandler(privacyService)
+		eraseAccountHandler := privacy.NewEraseAccountHandler(privacyService)
+		listSessionsHandler := sessions.NewListSessionsHandler(sessionService)
+		revokeSessionHandler := sessions.NewRevokeSessionHandler(sessionService)
+		mfaStatusHandler := twofactor.NewStatusHandler(twoFactorService)
+		mfaEnrollHandler := twofactor.NewEnrollHandler(twoFactorService)
+		mfaConfirmHandler := twofactor.NewConfirmHandler(twoFactorService)
+		mfaDisableHandler := twofactor.NewDisableHandler(twoFactorService)
+		mfaRecoveryCodesHandler := twofactor.NewRegenerateRecoveryCodesHandler(twoFactorService)
+		createAPIKeyHandler := apikeys.NewCreateAPIKeyHandler(apiKeyService)
+		listAPIKeysHandler := apikeys.NewListAPIKeysHandler(apiKeyService)
+		revokeAPIKeyHandler := apikeys.NewRevokeAPIKeyHandler(apiKeyService)
 		healthHandler := healthcheck.NewHealthCheckHandler()
+		jwksHandler := jwks.NewJWKSHandler(jwtManager)
 		circuitBreakerExampleHandler := circuitBreakerExample.NewExampleHandler()
+		listUsersHandler := admin.NewListUsersHandler(adminUserService)
+		getUserHandler := admin.NewGetUserHandler(adminUserService)
+		updateUserHandler := admin.NewUpdateUserHandler(adminUserService)
+		activateUserHandler := admin.NewActivateUserHandler(adminUserService)
+		deactivateUserHandler := admin.NewDeactivateUserHandler(adminUserService)
+		deleteUserHandler := admin.NewDeleteUserHandler(adminUserService)
+		listLockoutEventsHandler := admin.NewListLockoutEventsHandler(adminLockoutService)
+		listUserAPIKeysHandler := apikeys.NewListUserAPIKeysHandler(apiKeyService)
+		adminRevokeAPIKeyHandler := apikeys.NewAdminRevokeAPIKeyHandler(apiKeyService)
 
 		// Rate Limiter for healthcheck
 		healthCheckRateLimiter := middlewares.NewEndpointRateLimiter(
@@ -52,19 +199,112 @@ func NewRouteSetup(cfg *config.Config, db *sqlx.DB) infrahttp.RouteSetupFunc {
 			middlewares.KeyByIP,
 		)
 
+		// Rate Limiter for password reset requests
+		passwordResetRateLimiter := middlewares.NewEndpointRateLimiter(
+			5,
+			15*time.Minute,
+			infraredis.NewStorage(&cfg.Redis),
+			middlewares.KeyByIP,
+		)
+
+		// Rate Limiter for email verification requests
+		emailVerificationRateLimiter := middlewares.NewEndpointRateLimiter(
+			5,
+			15*time.Minute,
+			infraredis.NewStorage(&cfg.Redis),
+			middlewares.KeyByIP,
+		)
+
+		// Rate Limiter for the second login step
+		mfaRateLimiter := middlewares.NewEndpointRateLimiter(
+			10,
+			5*time.Minute,
+			infraredis.NewStorage(&cfg.Redis),
+			middlewares.KeyByIP,
+		)
+
 		// Public routes
 		s.Get("/healthcheck", infrahttp.AdaptHandler(healthHandler), healthCheckRateLimiter)
 		s.Get("/circuit-breaker-example", infrahttp.AdaptHandler(circuitBreakerExampleHandler))
 		s.Mount("/metrics", promhttp.Handler())
+		s.Get("/.well-known/jwks.json", infrahttp.AdaptHandler(jwksHandler))
 
 		// Auth routes (public)
 		authGroup := s.Group("/auth")
 		authGroup.Post("/register", infrahttp.AdaptHandler(registerHandler))
 		authGroup.Post("/login", infrahttp.AdaptHandler(loginHandler))
+		authGroup.Post("/mfa/verify", infrahttp.AdaptHandler(verifyMFAHandler), mfaRateLimiter)
+		authGroup.Get("/oidc/providers", infrahttp.AdaptHandler(oidcProvidersHandler))
+		authGroup.Get("/oidc/:provider/login", infrahttp.AdaptHandler(oidcLoginHandler))
+		authGroup.Get("/oidc/:provider/callback", infrahttp.AdaptHandler(oidcCallbackHandler))
+		authGroup.Post("/oidc/:provider/callback", infrahttp.AdaptHandler(oidcCallbackHandler))
 		authGroup.Post("/refresh", infrahttp.AdaptHandler(refreshTokenHandler))
+		authGroup.Post("/password/forgot", infrahttp.AdaptHandler(forgotPasswordHandler), passwordResetRateLimiter)
+		authGroup.Post("/password/reset", infrahttp.AdaptHandler(resetPasswordHandler), passwordResetRateLimiter)
+		authGroup.Get("/verify-email", infrahttp.AdaptHandler(verifyEmailHandler), emailVerificationRateLimiter)
+		authGroup.Post("/verify-email", infrahttp.AdaptHandler(verifyEmailHandler), emailVerificationRateLimiter)
+		authGroup.Post("/verify-email/resend", infrahttp.AdaptHandler(resendVerificationHandler), emailVerificationRateLimiter)
+
+		// Auth routes (require JWT authentication)
+		authMiddleware := middlewares.AuthMiddleware(jwtManager)
+		authGroup.Post("/logout", infrahttp.AdaptHandler(logoutHandler), authMiddleware)
+		authGroup.Post("/logout-all", infrahttp.AdaptHandler(logoutAllHandler), authMiddleware)
+
+		// Protected routes (require a JWT or an X-API-Key header)
+		// API keys act with the intersection of their scopes and the owner's permissions.
+		// Role or permission guards can be added per route or per group with
+		// middlewares.RequireRoles and middlewares.RequirePermission
+		apiAuthMiddleware := middlewares.Authenticate(
+			middlewares.JWTAuthenticator(jwtManager),
+			middlewares.APIKeyAuthenticator(apiKeyService),
+		)
+		apiGroup := s.Group("/api", apiAuthMiddleware)
+
+		// Changing credentials needs a user token, an API key must not be able to take over its account
+		requireUserToken := middlewares.RequireUserToken()
+		apiGroup.Get("/profile", infrahttp.AdaptHandler(profileHandler), middlewares.RequirePermission(role.PermissionProfileRead))
+		apiGroup.Patch("/profile", infrahttp.AdaptHandler(updateProfileHandler), middlewares.RequirePermission(role.PermissionProfileWrite))
+		apiGroup.Post("/profile/password", infrahttp.AdaptHandler(changePasswordHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+		apiGroup.Get("/profile/export", infrahttp.AdaptHandler(exportDataHandler), middlewares.RequirePermission(role.PermissionProfileRead))
+		apiGroup.Delete("/profile", infrahttp.AdaptHandler(eraseAccountHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+
+		// Session routes
+		apiGroup.Get("/sessions", infrahttp.AdaptHandler(listSessionsHandler), middlewares.RequirePermission(role.PermissionProfileRead))
+		apiGroup.Delete("/sessions/:id", infrahttp.AdaptHandler(revokeSessionHandler), middlewares.RequirePermission(role.PermissionProfileWrite))
+
+		// Two-factor authentication routes
+		apiGroup.Get("/mfa", infrahttp.AdaptHandler(mfaStatusHandler), middlewares.RequirePermission(role.PermissionProfileRead))
+		apiGroup.Post("/mfa/totp", infrahttp.AdaptHandler(mfaEnrollHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+		apiGroup.Post("/mfa/totp/confirm", infrahttp.AdaptHandler(mfaConfirmHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+		apiGroup.Post("/mfa/totp/disable", infrahttp.AdaptHandler(mfaDisableHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+		apiGroup.Post("/mfa/recovery-codes", infrahttp.AdaptHandler(mfaRecoveryCodesHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+
+		// API key routes
+		apiGroup.Get("/api-keys", infrahttp.AdaptHandler(listAPIKeysHandler), middlewares.RequirePermission(role.PermissionProfileRead))
+		apiGroup.Post("/api-keys", infrahttp.AdaptHandler(createAPIKeyHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+		apiGroup.Delete("/api-keys/:id", infrahttp.AdaptHandler(revokeAPIKeyHandler), middlewares.RequirePermission(role.PermissionProfileWrite), requireUserToken)
+
+		// Admin routes (require the admin role)
+		adminGroup := apiGroup.Group("/admin", middlewares.RequireRoles(role.Admin))
+		adminGroup.Get("/users", infrahttp.AdaptHandler(listUsersHandler), middlewares.RequirePermission(role.PermissionUsersRead))
+		adminGroup.Get("/users/:id", infrahttp.AdaptHandler(getUserHandler), middlewares.RequirePermission(role.PermissionUsersRead))
+		adminGroup.Patch("/users/:id", infrahttp.AdaptHandler(updateUserHandler), middlewares.RequirePermission(role.PermissionUsersWrite))
+		adminGroup.Post("/users/:id/activate", infrahttp.AdaptHandler(activateUserHandler), middlewares.RequirePermission(role.PermissionUsersWrite))
+		adminGroup.Post("/users/:id/deactivate", infrahttp.AdaptHandler(deactivateUserHandler), middlewares.RequirePermission(role.PermissionUsersWrite))
+		adminGroup.Delete("/users/:id", infrahttp.AdaptHandler(deleteUserHandler), middlewares.RequirePermission(role.PermissionUsersDelete))
+		adminGroup.Get("/lockouts", infrahttp.AdaptHandler(listLockoutEventsHandler), middlewares.RequirePermission(role.PermissionUsersRead))
+		adminGroup.Get("/users/:id/api-keys", infrahttp.AdaptHandler(listUserAPIKeysHandler), middlewares.RequirePermission(role.PermissionUsersRead))
+		adminGroup.Delete("/api-keys/:id", infrahttp.AdaptHandler(adminRevokeAPIKeyHandler), middlewares.RequirePermission(role.PermissionUsersWrite))
+	}
+}
 
-		// Protected routes (require JWT authentication)
-		apiGroup := s.Group("/api", middlewares.AuthMiddleware(jwtManager))
-		apiGroup.Get("/profile", infrahttp.AdaptHandler(profileHandler))
+// lockoutPolicy builds a login lockout policy with the given attempt limit
+func lockoutPolicy(cfg *config.LockoutConfig, maxAttempts int) lockout.Policy {
+	return lockout.Policy{
+		MaxAttempts:   maxAttempts,
+		AttemptWindow: cfg.AttemptWindow,
+		BaseDuration:  cfg.BaseDuration,
+		MaxDuration:   cfg.MaxDuration,
+		ResetAfter:    cfg.ResetAfter,
 	}
 }
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"encoding/base64"
+	"errors"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"strconv"
@@ -18,6 +21,8 @@ type Config struct {
 	Redis          RedisConfig          `mapstructure:"redis"`
 	Database       DatabaseConfig       `mapstructure:"database"`
 	JWT            JWTConfig            `mapstructure:"jwt"`
+	Auth           AuthConfig           `mapstructure:"auth"`
+	Mail           MailConfig           `mapstructure:"mail"`
 }
 
 // ServerConfig holds server-related configuration
@@ -39,10 +44,19 @@ type LoggerConfig struct {
 
 // RedisConfig holds Redis connection configuration
 type RedisConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
+	Host      string          `mapstructure:"host"`
+	Port      int             `mapstructure:"port"`
+	Password  string          `mapstructure:"password"`
+	DB        int             `mapstructure:"db"`
+	UserCache UserCacheConfig `mapstructure:"user_cache"`
+}
+
+// UserCacheConfig holds the Redis read-through cache for user lookups by ID
+// Unknown IDs are cached for NegativeTTL so repeated lookups do not reach the database
+type UserCacheConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	TTL         time.Duration `mapstructure:"ttl"`
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
 }
 
 // RateLimiterConfig holds rate limiter configuration
@@ -53,23 +67,195 @@ type RateLimiterConfig struct {
 }
 
 // DatabaseConfig holds database connection configuration
+// AutoMigrate applies pending migrations on startup, otherwise run `server migrate up`
+// Queries slower than SlowQueryThreshold are logged, 0 disables the log
 type DatabaseConfig struct {
-	Host            string        `mapstructure:"host"`
-	Port            int           `mapstructure:"port"`
-	User            string        `mapstructure:"user"`
-	Password        string        `mapstructure:"password"`
-	DBName          string        `mapstructure:"dbname"`
-	SSLMode         string        `mapstructure:"sslmode"`
-	MaxOpenConns    int           `mapstructure:"max_open_conns"`
-	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
-	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	Host               string               `mapstructure:"host"`
+	Port               int                  `mapstructure:"port"`
+	User               string               `mapstructure:"user"`
+	Password           string               `mapstructure:"password"`
+	DBName             string               `mapstructure:"dbname"`
+	SSLMode            string               `mapstructure:"sslmode"`
+	MaxOpenConns       int                  `mapstructure:"max_open_conns"`
+	MaxIdleConns       int                  `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime    time.Duration        `mapstructure:"conn_max_lifetime"`
+	AutoMigrate        bool                 `mapstructure:"auto_migrate"`
+	SlowQueryThreshold time.Duration        `mapstructure:"slow_query_threshold"`
+	Transaction        TransactionConfig    `mapstructure:"transaction"`
+	Replicas           []ReplicaConfig      `mapstructure:"replicas"`
+	ReplicaRouting     ReplicaRoutingConfig `mapstructure:"replica_routing"`
+}
+
+// ReplicaConfig holds the address of a read replica
+// Credentials, database name and pool settings are shared with the primary
+type ReplicaConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+}
+
+// ReplicaRoutingConfig holds how reads are spread over the replicas
+// Policy is "round_robin" or "least_conn", replicas failing the health check
+// run every HealthCheckInterval receive no reads until they recover
+type ReplicaRoutingConfig struct {
+	Policy              string        `mapstructure:"policy"`
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+}
+
+// TransactionConfig holds the defaults of transactions run by the transaction manager
+// Isolation is "read_committed", "repeatable_read" or "serializable", transactions
+// failing on a serialization conflict or deadlock are retried up to MaxRetries times
+type TransactionConfig struct {
+	Isolation  string        `mapstructure:"isolation"`
+	MaxRetries int           `mapstructure:"max_retries"`
+	RetryDelay time.Duration `mapstructure:"retry_delay"`
 }
 
 // JWTConfig holds JWT authentication configuration
+// HMAC algorithms (HS256/HS384/HS512) sign with Secret, asymmetric algorithms
+// (RS256, PS256, ES256, EdDSA, ...) sign with the PEM encoded key files.
+// If KeyDir contains a key named KeyID, that key signs instead.
 type JWTConfig struct {
-	Secret               string        `mapstructure:"secret"`
-	AccessTokenDuration  time.Duration `mapstructure:"access_token_duration"`
-	RefreshTokenDuration time.Duration `mapstructure:"refresh_token_duration"`
+	Algorithm            string         `mapstructure:"algorithm"`
+	KeyID                string         `mapstructure:"key_id"`
+	Secret               string         `mapstructure:"secret"`
+	PrivateKeyFile       string         `mapstructure:"private_key_file"`
+	PublicKeyFile        string         `mapstructure:"public_key_file"`
+	VerificationKeys     []JWTKeyConfig `mapstructure:"verification_keys"`
+	KeyDir               string         `mapstructure:"key_dir"`
+	KeyReloadInterval    time.Duration  `mapstructure:"key_reload_interval"`
+	AccessTokenDuration  time.Duration  `mapstructure:"access_token_duration"`
+	RefreshTokenDuration time.Duration  `mapstructure:"refresh_token_duration"`
+	MFATokenDuration     time.Duration  `mapstructure:"mfa_token_duration"`
+}
+
+// JWTKeyConfig holds a retiring key that is only used to verify tokens
+type JWTKeyConfig struct {
+	KeyID         string `mapstructure:"key_id"`
+	Algorithm     string `mapstructure:"algorithm"`
+	Secret        string `mapstructure:"secret"`
+	PublicKeyFile string `mapstructure:"public_key_file"`
+}
+
+// AuthConfig holds account management configuration
+type AuthConfig struct {
+	PasswordResetTokenTTL     time.Duration         `mapstructure:"password_reset_token_ttl"`
+	PasswordResetURL          string                `mapstructure:"password_reset_url"`
+	RequireEmailVerification  bool                  `mapstructure:"require_email_verification"`
+	EmailVerificationTokenTTL time.Duration         `mapstructure:"email_verification_token_ttl"`
+	EmailVerificationURL      string                `mapstructure:"email_verification_url"`
+	PasswordHashing           PasswordHashingConfig `mapstructure:"password_hashing"`
+	PasswordPolicy            PasswordPolicyConfig  `mapstructure:"password_policy"`
+	Lockout                   LockoutConfig         `mapstructure:"lockout"`
+	UserDeletion              UserDeletionConfig    `mapstructure:"user_deletion"`
+	MFA                       MFAConfig             `mapstructure:"mfa"`
+	OIDC                      OIDCConfig            `mapstructure:"oidc"`
+}
+
+// PasswordHashingConfig holds password hashing settings
+// Algorithm is "argon2id" or "bcrypt". Stored hashes of the other algorithm or
+// with outdated parameters are upgraded on the user's next successful login
+type PasswordHashingConfig struct {
+	Algorithm  string         `mapstructure:"algorithm"`
+	BcryptCost int            `mapstructure:"bcrypt_cost"`
+	Argon2id   Argon2idConfig `mapstructure:"argon2id"`
+}
+
+// PasswordPolicyConfig holds the rules enforced on new passwords at register, reset and change
+// History is the number of previous passwords that cannot be reused, 0 disables it
+type PasswordPolicyConfig struct {
+	MinLength        int               `mapstructure:"min_length"`
+	MaxLength        int               `mapstructure:"max_length"`
+	RequireUppercase bool              `mapstructure:"require_uppercase"`
+	RequireLowercase bool              `mapstructure:"require_lowercase"`
+	RequireDigit     bool              `mapstructure:"require_digit"`
+	RequireSymbol    bool              `mapstructure:"require_symbol"`
+	DisallowEmail    bool              `mapstructure:"disallow_email"`
+	History          int               `mapstructure:"history"`
+	BreachCheck      BreachCheckConfig `mapstructure:"breach_check"`
+}
+
+// BreachCheckConfig holds the breached password check settings
+// Provider is "hibp" (k-anonymity range API at HIBPURL), "file" (sorted SHA-1
+// hash file at File, for air-gapped deployments) or empty to disable the check
+type BreachCheckConfig struct {
+	Provider string        `mapstructure:"provider"`
+	HIBPURL  string        `mapstructure:"hibp_url"`
+	File     string        `mapstructure:"file"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+// Argon2idConfig holds argon2id cost parameters, Memory is in KiB
+type Argon2idConfig struct {
+	Memory      uint32 `mapstructure:"memory"`
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+	SaltLength  uint32 `mapstructure:"salt_length"`
+	KeyLength   uint32 `mapstructure:"key_length"`
+}
+
+// OIDCConfig holds OpenID Connect login settings
+// Providers are keyed by the name used in /auth/oidc/:provider routes,
+// providers without a client ID are disabled
+type OIDCConfig struct {
+	StateTTL  time.Duration                 `mapstructure:"state_ttl"`
+	Providers map[string]OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// OIDCProviderConfig holds the relying party registration at one OpenID provider
+type OIDCProviderConfig struct {
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// MFAConfig holds two-factor authentication settings
+// EncryptionKey is a base64 encoded 32 byte AES key protecting TOTP secrets at rest,
+// it has no default and must be provided, e.g. with AUTH_MFA_ENCRYPTION_KEY
+type MFAConfig struct {
+	Issuer        string `mapstructure:"issuer"`
+	EncryptionKey string `mapstructure:"encryption_key"`
+}
+
+// LockoutConfig holds brute-force protection settings for login
+// Failed attempts are counted per account and per client IP within AttemptWindow,
+// reaching the limit locks the key for BaseDuration, doubling on every repeated
+// lockout up to MaxDuration until no lockout happened for ResetAfter
+type LockoutConfig struct {
+	Enabled            bool          `mapstructure:"enabled"`
+	AccountMaxAttempts int           `mapstructure:"account_max_attempts"`
+	IPMaxAttempts      int           `mapstructure:"ip_max_attempts"`
+	AttemptWindow      time.Duration `mapstructure:"attempt_window"`
+	BaseDuration       time.Duration `mapstructure:"base_duration"`
+	MaxDuration        time.Duration `mapstructure:"max_duration"`
+	ResetAfter         time.Duration `mapstructure:"reset_after"`
+}
+
+// UserDeletionConfig holds settings for removing deleted accounts
+// Soft deleted users are purged permanently once Retention has passed,
+// the purge runs every PurgeInterval and is disabled when it is 0
+type UserDeletionConfig struct {
+	Retention     time.Duration `mapstructure:"retention"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// MailConfig holds outgoing email configuration
+// Driver is one of "smtp", "log" or "memory"
+type MailConfig struct {
+	Driver string     `mapstructure:"driver"`
+	From   string     `mapstructure:"from"`
+	SMTP   SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig holds SMTP server configuration
+// Timeout bounds the delivery of a single email
+type SMTPConfig struct {
+	Host     string        `mapstructure:"host"`
+	Port     int           `mapstructure:"port"`
+	Username string        `mapstructure:"username"`
+	Password string        `mapstructure:"password"`
+	Timeout  time.Duration `mapstructure:"timeout"`
 }
 
 
@@ -89,7 +275,8 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
-	// Environment Variables
+	// Environment Variables, e.g. DATABASE_HOST overrides database.host
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
 	// Unmarshal into Config struct
@@ -98,9 +285,22 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// validate rejects settings the application must not start with
+func (c *Config) validate() error {
+	key, err := base64.StdEncoding.DecodeString(c.Auth.MFA.EncryptionKey)
+	if err != nil || len(key) != 32 {
+		return errors.New("auth.mfa.encryption_key must be a base64 encoded 32 byte key, generate one with `openssl rand -base64 32`")
+	}
+	return nil
+}
+
 func GetBaseDir() string {
 	// Get the current file's directory
 	_, filename, _, ok := runtime.Caller(0)
@@ -138,6 +338,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("redis.port", 6379)
 	v.SetDefault("redis.password", "")
 	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.user_cache.enabled", false)
+	v.SetDefault("redis.user_cache.ttl", 5*time.Minute)
+	v.SetDefault("redis.user_cache.negative_ttl", 30*time.Second)
 
 	// Database defaults
 	v.SetDefault("database.host", "localhost")
@@ -149,9 +352,70 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.max_open_conns", 25)
 	v.SetDefault("database.max_idle_conns", 5)
 	v.SetDefault("database.conn_max_lifetime", 5*time.Minute)
+	v.SetDefault("database.auto_migrate", false)
+	v.SetDefault("database.slow_query_threshold", 200*time.Millisecond)
+	v.SetDefault("database.replica_routing.policy", "round_robin")
+	v.SetDefault("database.replica_routing.health_check_interval", 10*time.Second)
+	v.SetDefault("database.transaction.isolation", "read_committed")
+	v.SetDefault("database.transaction.max_retries", 3)
+	v.SetDefault("database.transaction.retry_delay", 10*time.Millisecond)
 
 	// JWT defaults
+	v.SetDefault("jwt.algorithm", "HS256")
+	v.SetDefault("jwt.key_id", "")
 	v.SetDefault("jwt.secret", "your-secret-key-change-this-in-production")
+	v.SetDefault("jwt.private_key_file", "")
+	v.SetDefault("jwt.public_key_file", "")
+	v.SetDefault("jwt.key_dir", "")
+	v.SetDefault("jwt.key_reload_interval", 0)
 	v.SetDefault("jwt.access_token_duration", 15*time.Minute)
 	v.SetDefault("jwt.refresh_token_duration", 168*time.Hour) // 7 days
+	v.SetDefault("jwt.mfa_token_duration", 5*time.Minute)
+
+	// Auth defaults
+	v.SetDefault("auth.password_reset_token_ttl", 1*time.Hour)
+	v.SetDefault("auth.password_reset_url", "http://localhost:3000/reset-password")
+	v.SetDefault("auth.require_email_verification", false)
+	v.SetDefault("auth.email_verification_token_ttl", 24*time.Hour)
+	v.SetDefault("auth.email_verification_url", "http://localhost:8000/auth/verify-email")
+	v.SetDefault("auth.password_hashing.algorithm", "argon2id")
+	v.SetDefault("auth.password_hashing.bcrypt_cost", 10)
+	v.SetDefault("auth.password_hashing.argon2id.memory", 19456) // 19 MiB
+	v.SetDefault("auth.password_hashing.argon2id.iterations", 2)
+	v.SetDefault("auth.password_hashing.argon2id.parallelism", 1)
+	v.SetDefault("auth.password_hashing.argon2id.salt_length", 16)
+	v.SetDefault("auth.password_hashing.argon2id.key_length", 32)
+	v.SetDefault("auth.password_policy.min_length", 8)
+	v.SetDefault("auth.password_policy.max_length", 128)
+	v.SetDefault("auth.password_policy.require_uppercase", false)
+	v.SetDefault("auth.password_policy.require_lowercase", false)
+	v.SetDefault("auth.password_policy.require_digit", false)
+	v.SetDefault("auth.password_policy.require_symbol", false)
+	v.SetDefault("auth.password_policy.disallow_email", true)
+	v.SetDefault("auth.password_policy.history", 5)
+	v.SetDefault("auth.password_policy.breach_check.provider", "")
+	v.SetDefault("auth.password_policy.breach_check.hibp_url", "https://api.pwnedpasswords.com")
+	v.SetDefault("auth.password_policy.breach_check.file", "")
+	v.SetDefault("auth.password_policy.breach_check.timeout", 3*time.Second)
+	v.SetDefault("auth.lockout.enabled", true)
+	v.SetDefault("auth.lockout.account_max_attempts", 5)
+	v.SetDefault("auth.lockout.ip_max_attempts", 20)
+	v.SetDefault("auth.lockout.attempt_window", 15*time.Minute)
+	v.SetDefault("auth.lockout.base_duration", 1*time.Minute)
+	v.SetDefault("auth.lockout.max_duration", 1*time.Hour)
+	v.SetDefault("auth.lockout.reset_after", 24*time.Hour)
+	v.SetDefault("auth.user_deletion.retention", 30*24*time.Hour)
+	v.SetDefault("auth.user_deletion.purge_interval", 24*time.Hour)
+	v.SetDefault("auth.mfa.issuer", "Microservice Starter")
+	v.SetDefault("auth.oidc.state_ttl", 10*time.Minute)
+	v.SetDefault("auth.mfa.encryption_key", "")
+
+	// Mail defaults
+	v.SetDefault("mail.driver", "smtp")
+	v.SetDefault("mail.from", "no-reply@example.com")
+	v.SetDefault("mail.smtp.host", "localhost")
+	v.SetDefault("mail.smtp.port", 587)
+	v.SetDefault("mail.smtp.username", "")
+	v.SetDefault("mail.smtp.password", "")
+	v.SetDefault("mail.smtp.timeout", 30*time.Second)
 }
@@ -0,0 +1,146 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// Repository defines the interface for API key data operations
+type Repository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByID(ctx context.Context, id int64) (*APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	ListByUser(ctx context.Context, userID int64) ([]*APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+	RevokeForUser(ctx context.Context, userID int64) error
+	TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new API key repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+const selectAPIKey = `
+	SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	FROM api_keys
+`
+
+// Create inserts a new API key
+func (r *repository) Create(ctx context.Context, key *APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	key.CreatedAt = time.Now().UTC()
+
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		key.ExpiresAt,
+		key.CreatedAt,
+	).Scan(&key.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", database.Translate(err))
+	}
+
+	return nil
+}
+
+// GetByID retrieves an API key by its ID
+func (r *repository) GetByID(ctx context.Context, id int64) (*APIKey, error) {
+	return r.get(ctx, selectAPIKey+`WHERE id = $1`, id)
+}
+
+// GetByHash retrieves an API key by the hash of the key
+func (r *repository) GetByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	return r.get(ctx, selectAPIKey+`WHERE key_hash = $1`, keyHash)
+}
+
+func (r *repository) get(ctx context.Context, query string, args ...interface{}) (*APIKey, error) {
+	var key APIKey
+	err := r.db.Writer(ctx).GetContext(ctx, &key, query, args...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", database.Translate(err))
+	}
+
+	return &key, nil
+}
+
+// ListByUser retrieves every API key of a user, newest first
+func (r *repository) ListByUser(ctx context.Context, userID int64) ([]*APIKey, error) {
+	keys := []*APIKey{}
+	if err := r.db.Reader(ctx).SelectContext(ctx, &keys, selectAPIKey+`WHERE user_id = $1 ORDER BY created_at DESC, id DESC`, userID); err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", database.Translate(err))
+	}
+
+	return keys, nil
+}
+
+// Revoke marks an API key as revoked, revoking an already revoked key is a no-op
+func (r *repository) Revoke(ctx context.Context, id int64) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2`
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// RevokeForUser revokes every API key of a user
+func (r *repository) RevokeForUser(ctx context.Context, userID int64) error {
+	query := `UPDATE api_keys SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), userID); err != nil {
+		return fmt.Errorf("failed to revoke API keys: %w", database.Translate(err))
+	}
+
+	return nil
+}
+
+// TouchLastUsed records when the key was last used
+func (r *repository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, usedAt, id); err != nil {
+		return fmt.Errorf("failed to update API key: %w", database.Translate(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package identity
+
+import "time"
+
+// Identity links a user to an account at an external identity provider
+// Subject is the provider's stable, unique identifier of the account
+type Identity struct {
+	ID          int64      `db:"id" json:"id"`
+	UserID      int64      `db:"user_id" json:"user_id"`
+	Provider    string     `db:"provider" json:"provider"`
+	Subject     string     `db:"subject" json:"subject"`
+	Email       *string    `db:"email" json:"email,omitempty"`
+	LastLoginAt *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,117 @@
+package identity
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+)
+
+var (
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to a user")
+)
+
+// providerSubjectConstraint is the unique constraint on the provider account of an identity
+var providerSubjectConstraint = database.Constraint{Name: "user_identities_provider_subject_key", Err: ErrIdentityAlreadyLinked}
+
+// Repository defines the interface for external identity data operations
+type Repository interface {
+	Create(ctx context.Context, identity *Identity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*Identity, error)
+	ListByUser(ctx context.Context, userID int64) ([]*Identity, error)
+	TouchLastLogin(ctx context.Context, id int64, email *string) error
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new identity repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+// Create links a new external identity to a user
+func (r *repository) Create(ctx context.Context, identity *Identity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	identity.CreatedAt = time.Now().UTC()
+
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		identity.LastLoginAt,
+		identity.CreatedAt,
+	).Scan(&identity.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create identity: %w", database.Translate(err, providerSubjectConstraint))
+	}
+
+	return nil
+}
+
+// GetByProviderSubject retrieves the identity of a provider account
+func (r *repository) GetByProviderSubject(ctx context.Context, provider, subject string) (*Identity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2
+	`
+
+	var identity Identity
+	err := r.db.Writer(ctx).GetContext(ctx, &identity, query, provider, subject)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("failed to get identity: %w", database.Translate(err))
+	}
+
+	return &identity, nil
+}
+
+// ListByUser retrieves every identity linked to a user
+func (r *repository) ListByUser(ctx context.Context, userID int64) ([]*Identity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY id
+	`
+
+	identities := []*Identity{}
+	if err := r.db.Reader(ctx).SelectContext(ctx, &identities, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list identities: %w", database.Translate(err))
+	}
+
+	return identities, nil
+}
+
+// TouchLastLogin records a login through the identity and the email the provider reported
+func (r *repository) TouchLastLogin(ctx context.Context, id int64, email *string) error {
+	query := `
+		UPDATE user_identities
+		SET last_login_at = $1, email = COALESCE($2, email)
+		WHERE id = $3
+	`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), email, id); err != nil {
+		return fmt.Errorf("failed to update identity: %w", database.Translate(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Scope identifies what a failed login attempt is counted against
+type Scope string
+
+const (
+	ScopeAccount Scope = "account"
+	ScopeIP      Scope = "ip"
+)
+
+// Subject is a single key failed login attempts are counted against,
+// an email address for ScopeAccount or a client IP for ScopeIP
+type Subject struct {
+	Scope      Scope
+	Identifier string
+}
+
+// Policy configures when a subject gets locked and for how long
+// The n-th consecutive lockout lasts BaseDuration * 2^(n-1), capped at MaxDuration,
+// and the count of consecutive lockouts is forgotten after ResetAfter
+type Policy struct {
+	MaxAttempts   int
+	AttemptWindow time.Duration
+	BaseDuration  time.Duration
+	MaxDuration   time.Duration
+	ResetAfter    time.Duration
+}
+
+// Failure is the outcome of recording a failed login attempt
+type Failure struct {
+	Attempts  int
+	LockedFor time.Duration
+}
+
+// Locked reports whether the failure locked the subject
+func (f Failure) Locked() bool {
+	return f.LockedFor > 0
+}
+
+// Tracker counts failed login attempts and locks subjects exceeding their policy
+// Locks expire on their own, no explicit unlock is needed
+type Tracker interface {
+	// LockedFor returns the longest remaining lock among the given subjects, zero if none is locked
+	LockedFor(ctx context.Context, subjects ...Subject) (time.Duration, error)
+	// RecordFailure counts a failed attempt and locks the subject once it reaches the policy's limit
+	RecordFailure(ctx context.Context, subject Subject, policy Policy) (Failure, error)
+	// Reset forgets the failed attempts and previous lockouts of the subject
+	Reset(ctx context.Context, subject Subject) error
+}
+
+// Event records a lockout for auditing
+type Event struct {
+	ID             int64     `db:"id" json:"id"`
+	UserID         *int64    `db:"user_id" json:"user_id,omitempty"`
+	Scope          Scope     `db:"scope" json:"scope"`
+	Identifier     string    `db:"identifier" json:"identifier"`
+	IPAddress      *string   `db:"ip_address" json:"ip_address,omitempty"`
+	FailedAttempts int       `db:"failed_attempts" json:"failed_attempts"`
+	LockedUntil    time.Time `db:"locked_until" json:"locked_until"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,127 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/pagination"
+)
+
+// ListSpec whitelists the fields lockout events can be sorted by, newest first by default
+var ListSpec = pagination.Spec[*Event]{
+	Fields: map[string]pagination.Field[*Event]{
+		"id":           {Column: "id", Value: func(e *Event) any { return e.ID }},
+		"created_at":   {Column: "created_at", Value: func(e *Event) any { return e.CreatedAt }},
+		"locked_until": {Column: "locked_until", Value: func(e *Event) any { return e.LockedUntil }},
+	},
+	Default:    "-created_at",
+	Tiebreaker: "id",
+}
+
+// ListFilter narrows a list of lockout events, zero fields do not filter
+type ListFilter struct {
+	UserID       *int64
+	Scope        Scope
+	Identifier   string
+	CreatedAfter *time.Time
+}
+
+// filters converts the filter to query conditions
+func (f ListFilter) filters() []pagination.Filter {
+	return []pagination.Filter{
+		pagination.Eq("user_id", f.UserID),
+		pagination.Eq("scope", f.Scope),
+		pagination.Contains("identifier", f.Identifier),
+		pagination.Gte("created_at", f.CreatedAfter),
+	}
+}
+
+// Repository defines the interface for lockout event data operations
+type Repository interface {
+	Create(ctx context.Context, event *Event) error
+	List(ctx context.Context, filter ListFilter, query *pagination.Query[*Event]) ([]*Event, int64, error)
+	ListByUser(ctx context.Context, userID int64) ([]*Event, error)
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new lockout event repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+// Create inserts a new lockout event
+func (r *repository) Create(ctx context.Context, event *Event) error {
+	query := `
+		INSERT INTO lockout_events (user_id, scope, identifier, ip_address, failed_attempts, locked_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	event.CreatedAt = time.Now().UTC()
+
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		query,
+		event.UserID,
+		event.Scope,
+		event.Identifier,
+		event.IPAddress,
+		event.FailedAttempts,
+		event.LockedUntil,
+		event.CreatedAt,
+	).Scan(&event.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create lockout event: %w", database.Translate(err))
+	}
+
+	return nil
+}
+
+// List retrieves a page of the lockout events matching the filter and the number of all matching events
+func (r *repository) List(ctx context.Context, filter ListFilter, query *pagination.Query[*Event]) ([]*Event, int64, error) {
+	query = query.Filter(filter.filters()...)
+	db := r.db.Reader(ctx)
+
+	where, args := query.Where()
+	selectQuery := db.Rebind(`
+		SELECT id, user_id, scope, identifier, ip_address, failed_attempts, locked_until, created_at
+		FROM lockout_events` + where + query.OrderBy() + query.Limit())
+
+	events := []*Event{}
+	if err := db.SelectContext(ctx, &events, selectQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list lockout events: %w", database.Translate(err))
+	}
+
+	countWhere, countArgs := query.CountWhere()
+
+	var total int64
+	if err := db.GetContext(ctx, &total, db.Rebind(`SELECT COUNT(*) FROM lockout_events`+countWhere), countArgs...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count lockout events: %w", database.Translate(err))
+	}
+
+	return events, total, nil
+}
+
+// ListByUser retrieves all lockout events of a user, newest first
+func (r *repository) ListByUser(ctx context.Context, userID int64) ([]*Event, error) {
+	query := `
+		SELECT id, user_id, scope, identifier, ip_address, failed_attempts, locked_until, created_at
+		FROM lockout_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	events := []*Event{}
+	if err := r.db.Reader(ctx).SelectContext(ctx, &events, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list lockout events by user: %w", database.Translate(err))
+	}
+
+	return events, nil
+}
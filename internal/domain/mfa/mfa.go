@@ -0,0 +1,62 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount is the number of recovery codes issued at once
+const RecoveryCodeCount = 10
+
+// TOTP holds a user's TOTP enrollment
+// The shared secret is stored encrypted, LastUsedStep prevents replaying a code
+type TOTP struct {
+	UserID          int64      `db:"user_id" json:"user_id"`
+	SecretEncrypted string     `db:"secret_encrypted" json:"-"`
+	ConfirmedAt     *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"`
+	LastUsedStep    int64      `db:"last_used_step" json:"-"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// IsEnabled reports whether the enrollment was confirmed with a first code
+func (t *TOTP) IsEnabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCodes returns RecoveryCodeCount new codes formatted as XXXX-XXXX
+// and their hashes to be stored
+func GenerateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := recoveryEncoding.EncodeToString(b)
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, HashRecoveryCode(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the SHA-256 hex digest of a recovery code,
+// ignoring case, spaces and dashes
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(code)
+	normalized = strings.NewReplacer("-", "", " ", "").Replace(normalized)
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,219 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+)
+
+var (
+	ErrNotEnrolled          = errors.New("two-factor authentication is not enrolled")
+	ErrStepAlreadyUsed      = errors.New("TOTP code has already been used")
+	ErrRecoveryCodeNotFound = errors.New("recovery code not found or already used")
+	ErrInvalidCode          = errors.New("invalid two-factor authentication code")
+	ErrAlreadyEnabled       = errors.New("two-factor authentication is already enabled")
+)
+
+// Repository defines the interface for two-factor authentication data operations
+type Repository interface {
+	GetTOTP(ctx context.Context, userID int64) (*TOTP, error)
+	SaveTOTP(ctx context.Context, totp *TOTP) error
+	ConfirmTOTP(ctx context.Context, userID int64, step int64) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	DeleteTOTP(ctx context.Context, userID int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+	CountRecoveryCodes(ctx context.Context, userID int64) (int, error)
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new two-factor authentication repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+// GetTOTP retrieves the TOTP enrollment of a user
+func (r *repository) GetTOTP(ctx context.Context, userID int64) (*TOTP, error) {
+	query := `
+		SELECT user_id, secret_encrypted, confirmed_at, last_used_step, created_at, updated_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+
+	var totp TOTP
+	err := r.db.Writer(ctx).GetContext(ctx, &totp, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotEnrolled
+		}
+		return nil, fmt.Errorf("failed to get TOTP enrollment: %w", database.Translate(err))
+	}
+
+	return &totp, nil
+}
+
+// SaveTOTP starts a new, unconfirmed enrollment replacing any pending one
+func (r *repository) SaveTOTP(ctx context.Context, totp *TOTP) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret_encrypted, confirmed_at, last_used_step, created_at, updated_at)
+		VALUES ($1, $2, NULL, 0, $3, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, confirmed_at = NULL, last_used_step = 0, updated_at = EXCLUDED.updated_at
+		WHERE user_totp.confirmed_at IS NULL
+	`
+
+	now := time.Now().UTC()
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, totp.UserID, totp.SecretEncrypted, now)
+	if err != nil {
+		return fmt.Errorf("failed to save TOTP enrollment: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAlreadyEnabled
+	}
+
+	totp.ConfirmedAt = nil
+	totp.LastUsedStep = 0
+	totp.CreatedAt = now
+	totp.UpdatedAt = now
+	return nil
+}
+
+// ConfirmTOTP enables a pending enrollment, recording the step of the confirming code
+func (r *repository) ConfirmTOTP(ctx context.Context, userID int64, step int64) error {
+	query := `
+		UPDATE user_totp
+		SET confirmed_at = $1, last_used_step = $2, updated_at = $1
+		WHERE user_id = $3 AND confirmed_at IS NULL
+	`
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to confirm TOTP enrollment: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotEnrolled
+	}
+
+	return nil
+}
+
+// UseTOTPStep atomically records step as used, failing if it or a later step was used already
+func (r *repository) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	query := `
+		UPDATE user_totp
+		SET last_used_step = $1, updated_at = $2
+		WHERE user_id = $3 AND last_used_step < $1
+	`
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, step, time.Now().UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to use TOTP step: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrStepAlreadyUsed
+	}
+
+	return nil
+}
+
+// DeleteTOTP removes the enrollment and the recovery codes of a user
+func (r *repository) DeleteTOTP(ctx context.Context, userID int64) error {
+	return database.InTx(ctx, r.db.Primary(), nil, func(ctx context.Context) error {
+		if _, err := r.db.Writer(ctx).ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", database.Translate(err))
+		}
+
+		if _, err := r.db.Writer(ctx).ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete TOTP enrollment: %w", database.Translate(err))
+		}
+
+		return nil
+	})
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes and stores the given ones
+func (r *repository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	return database.InTx(ctx, r.db.Primary(), nil, func(ctx context.Context) error {
+		if _, err := r.db.Writer(ctx).ExecContext(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", database.Translate(err))
+		}
+
+		now := time.Now().UTC()
+		for _, codeHash := range codeHashes {
+			if _, err := r.db.Writer(ctx).ExecContext(
+				ctx,
+				`INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`,
+				userID,
+				codeHash,
+				now,
+			); err != nil {
+				return fmt.Errorf("failed to create recovery code: %w", database.Translate(err))
+			}
+		}
+
+		return nil
+	})
+}
+
+// ConsumeRecoveryCode atomically marks an unused recovery code as used
+func (r *repository) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	query := `
+		UPDATE mfa_recovery_codes
+		SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to consume recovery code: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecoveryCodeNotFound
+	}
+
+	return nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes of a user
+func (r *repository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var count int
+	if err := r.db.Writer(ctx).GetContext(ctx, &count, query, userID); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", database.Translate(err))
+	}
+
+	return count, nil
+}
@@ -0,0 +1,74 @@
+package passwordhistory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+)
+
+// Repository keeps the previous password hashes of users
+type Repository interface {
+	Add(ctx context.Context, userID int64, passwordHash string) error
+	ListRecent(ctx context.Context, userID int64, limit int) ([]string, error)
+	Prune(ctx context.Context, userID int64, keep int) error
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new password history repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+// Add records a password hash of the user
+func (r *repository) Add(ctx context.Context, userID int64, passwordHash string) error {
+	query := `INSERT INTO password_history (user_id, password_hash, created_at) VALUES ($1, $2, $3)`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, userID, passwordHash, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to add password history: %w", database.Translate(err))
+	}
+
+	return nil
+}
+
+// ListRecent retrieves the most recent password hashes of the user, newest first
+func (r *repository) ListRecent(ctx context.Context, userID int64, limit int) ([]string, error) {
+	query := `
+		SELECT password_hash
+		FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	hashes := []string{}
+	if err := r.db.Writer(ctx).SelectContext(ctx, &hashes, query, userID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list password history: %w", database.Translate(err))
+	}
+
+	return hashes, nil
+}
+
+// Prune deletes all but the most recent keep password hashes of the user
+func (r *repository) Prune(ctx context.Context, userID int64, keep int) error {
+	query := `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history
+			WHERE user_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		)
+	`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, userID, keep); err != nil {
+		return fmt.Errorf("failed to prune password history: %w", database.Translate(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+)
+
+// Repository defines the interface for role data operations
+type Repository interface {
+	GetUserAccess(ctx context.Context, userID int64) (*UserAccess, error)
+	AssignRole(ctx context.Context, userID int64, roleName string) error
+	RemoveRole(ctx context.Context, userID int64, roleName string) error
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new role repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+// GetUserAccess retrieves the role and permission names granted to a user
+func (r *repository) GetUserAccess(ctx context.Context, userID int64) (*UserAccess, error) {
+	rolesQuery := `
+		SELECT r.name
+		FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = $1
+		ORDER BY r.name
+	`
+
+	access := &UserAccess{
+		Roles:       []string{},
+		Permissions: []string{},
+	}
+
+	if err := r.db.Writer(ctx).SelectContext(ctx, &access.Roles, rolesQuery, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", database.Translate(err))
+	}
+
+	permissionsQuery := `
+		SELECT DISTINCT p.name
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		JOIN user_roles ur ON ur.role_id = rp.role_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name
+	`
+
+	if err := r.db.Writer(ctx).SelectContext(ctx, &access.Permissions, permissionsQuery, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user permissions: %w", database.Translate(err))
+	}
+
+	return access, nil
+}
+
+// AssignRole grants the named role to a user, assigning an already granted role is a no-op
+func (r *repository) AssignRole(ctx context.Context, userID int64, roleName string) error {
+	query := `
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM roles WHERE name = $2
+		ON CONFLICT (user_id, role_id) DO NOTHING
+	`
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, userID, roleName)
+	if err != nil {
+		return fmt.Errorf("failed to assign role: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// Either the role does not exist or it was already assigned
+		var exists bool
+		if err := r.db.Writer(ctx).GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1)`, roleName); err != nil {
+			return fmt.Errorf("failed to check role: %w", database.Translate(err))
+		}
+		if !exists {
+			return ErrRoleNotFound
+		}
+	}
+
+	return nil
+}
+
+// RemoveRole revokes the named role from a user
+func (r *repository) RemoveRole(ctx context.Context, userID int64, roleName string) error {
+	query := `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)
+	`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, userID, roleName); err != nil {
+		return fmt.Errorf("failed to remove role: %w", database.Translate(err))
+	}
+
+	return nil
+}
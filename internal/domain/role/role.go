@@ -0,0 +1,32 @@
+package role
+
+// Built-in roles seeded by the migrations
+const (
+	Admin = "admin"
+	User  = "user"
+
+	// DefaultRole is assigned to every newly registered user
+	DefaultRole = User
+)
+
+// Built-in permissions seeded by the migrations
+const (
+	PermissionUsersRead    = "users:read"
+	PermissionUsersWrite   = "users:write"
+	PermissionUsersDelete  = "users:delete"
+	PermissionProfileRead  = "profile:read"
+	PermissionProfileWrite = "profile:write"
+)
+
+// Role represents a named set of permissions
+type Role struct {
+	ID          int64   `db:"id" json:"id"`
+	Name        string  `db:"name" json:"name"`
+	Description *string `db:"description" json:"description,omitempty"`
+}
+
+// UserAccess holds the roles and permissions granted to a user
+type UserAccess struct {
+	Roles       []string
+	Permissions []string
+}
@@ -0,0 +1,170 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Repository defines the interface for session data operations
+type Repository interface {
+	Create(ctx context.Context, session *Session) error
+	GetByID(ctx context.Context, id string) (*Session, error)
+	ListByUser(ctx context.Context, userID int64) ([]*Session, error)
+	ListActiveByUser(ctx context.Context, userID int64) ([]*Session, error)
+	Touch(ctx context.Context, id, userAgent, ipAddress string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id string) error
+	RevokeForUser(ctx context.Context, userID int64) error
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new session repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+// Create inserts a new session
+func (r *repository) Create(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, device, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	now := time.Now().UTC()
+	session.CreatedAt = now
+	session.LastUsedAt = now
+
+	_, err := r.db.Writer(ctx).ExecContext(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		session.Device,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt,
+		session.LastUsedAt,
+		session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", database.Translate(err))
+	}
+
+	return nil
+}
+
+// GetByID retrieves a session by its ID
+func (r *repository) GetByID(ctx context.Context, id string) (*Session, error) {
+	query := `
+		SELECT id, user_id, device, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE id = $1
+	`
+
+	var session Session
+	err := r.db.Writer(ctx).GetContext(ctx, &session, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", database.Translate(err))
+	}
+
+	return &session, nil
+}
+
+// ListByUser retrieves every session of a user including revoked and expired ones, newest first
+func (r *repository) ListByUser(ctx context.Context, userID int64) ([]*Session, error) {
+	query := `
+		SELECT id, user_id, device, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	sessions := []*Session{}
+	if err := r.db.Reader(ctx).SelectContext(ctx, &sessions, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", database.Translate(err))
+	}
+
+	return sessions, nil
+}
+
+// ListActiveByUser retrieves the unexpired, unrevoked sessions of a user, most recently used first
+func (r *repository) ListActiveByUser(ctx context.Context, userID int64) ([]*Session, error) {
+	query := `
+		SELECT id, user_id, device, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC
+	`
+
+	sessions := []*Session{}
+	if err := r.db.Reader(ctx).SelectContext(ctx, &sessions, query, userID, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", database.Translate(err))
+	}
+
+	return sessions, nil
+}
+
+// Touch records a use of the session from the given client and extends its expiry
+func (r *repository) Touch(ctx context.Context, id, userAgent, ipAddress string, expiresAt time.Time) error {
+	userAgent = truncateUserAgent(userAgent)
+
+	query := `
+		UPDATE sessions
+		SET device = $1, user_agent = $2, ip_address = $3, last_used_at = $4, expires_at = $5
+		WHERE id = $6
+	`
+
+	_, err := r.db.Writer(ctx).ExecContext(ctx, query, DescribeDevice(userAgent), userAgent, ipAddress, time.Now().UTC(), expiresAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", database.Translate(err))
+	}
+
+	return nil
+}
+
+// Revoke marks a session as revoked, revoking an already revoked session is a no-op
+func (r *repository) Revoke(ctx context.Context, id string) error {
+	query := `UPDATE sessions SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2`
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeForUser revokes every session of a user
+func (r *repository) RevokeForUser(ctx context.Context, userID int64) error {
+	query := `UPDATE sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", database.Translate(err))
+	}
+
+	return nil
+}
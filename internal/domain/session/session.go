@@ -0,0 +1,105 @@
+package session
+
+import (
+	"strings"
+	"time"
+)
+
+// maxUserAgentLength matches the user_agent column size
+const maxUserAgentLength = 512
+
+// Session represents a logged in device
+// ID is the JWT session ID shared by every token of the refresh token family
+type Session struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     int64      `db:"user_id" json:"user_id"`
+	Device     string     `db:"device" json:"device"`
+	UserAgent  string     `db:"user_agent" json:"user_agent"`
+	IPAddress  string     `db:"ip_address" json:"ip_address"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// IsRevoked reports whether the session has been revoked
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// New creates a session for the given client
+func New(id string, userID int64, userAgent, ipAddress string, expiresAt time.Time) *Session {
+	userAgent = truncateUserAgent(userAgent)
+
+	return &Session{
+		ID:        id,
+		UserID:    userID,
+		Device:    DescribeDevice(userAgent),
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// truncateUserAgent cuts overly long user agents to the column size
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= maxUserAgentLength {
+		return userAgent
+	}
+	return strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+}
+
+// DescribeDevice returns a short human readable description of a user agent,
+// e.g. "Chrome on macOS". Unknown clients are described as "Unknown device"
+func DescribeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := match(userAgent, []pattern{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"Go-http-client", "Go HTTP client"},
+		{"okhttp", "OkHttp"},
+		{"PostmanRuntime", "Postman"},
+	})
+	os := match(userAgent, []pattern{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Unknown device"
+	}
+}
+
+type pattern struct {
+	token string
+	name  string
+}
+
+// match returns the name of the first pattern found in the user agent
+func match(userAgent string, patterns []pattern) string {
+	for _, p := range patterns {
+		if strings.Contains(userAgent, p.token) {
+			return p.name
+		}
+	}
+	return ""
+}
@@ -7,7 +7,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/pagination"
 )
 
 var (
@@ -15,30 +16,74 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 )
 
+// emailConstraint is the unique index on the email of users that have not been deleted
+var emailConstraint = database.Constraint{Name: "users_email_key", Err: ErrUserAlreadyExists}
+
+// ListSpec whitelists the fields users can be sorted by, by ID unless the request sorts otherwise
+var ListSpec = pagination.Spec[*User]{
+	Fields: map[string]pagination.Field[*User]{
+		"id":         {Column: "id", Value: func(u *User) any { return u.ID }},
+		"email":      {Column: "email", Value: func(u *User) any { return u.Email }},
+		"created_at": {Column: "created_at", Value: func(u *User) any { return u.CreatedAt }},
+		"updated_at": {Column: "updated_at", Value: func(u *User) any { return u.UpdatedAt }},
+	},
+	Default:    "id",
+	Tiebreaker: "id",
+}
+
+// ListFilter narrows a list of users, zero fields do not filter
+type ListFilter struct {
+	Email         string
+	IsActive      *bool
+	EmailVerified *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// filters converts the filter to query conditions
+func (f ListFilter) filters() []pagination.Filter {
+	var unverified *bool
+	if f.EmailVerified != nil {
+		unverified = new(bool)
+		*unverified = !*f.EmailVerified
+	}
+
+	return []pagination.Filter{
+		pagination.Contains("email", f.Email),
+		pagination.Eq("is_active", f.IsActive),
+		pagination.Null("email_verified_at", unverified),
+		pagination.Gte("created_at", f.CreatedAfter),
+		pagination.Lt("created_at", f.CreatedBefore),
+	}
+}
+
 // Repository defines the interface for user data operations
 type Repository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id int64) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	List(ctx context.Context, filter ListFilter, query *pagination.Query[*User]) ([]*User, int64, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id int64) error
+	Erase(ctx context.Context, id int64) error
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 // repository implements the Repository interface using sqlx
 type repository struct {
-	db *sqlx.DB
+	db *database.Router
 }
 
 // NewRepository creates a new user repository
-func NewRepository(db *sqlx.DB) Repository {
+func NewRepository(db *database.Router) Repository {
 	return &repository{db: db}
 }
 
 // Create inserts a new user into the database
 func (r *repository) Create(ctx context.Context, user *User) error {
 	query := `
-		INSERT INTO users (email, password_hash, first_name, last_name, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (email, password_hash, first_name, last_name, is_active, email_verified_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -46,7 +91,7 @@ func (r *repository) Create(ctx context.Context, user *User) error {
 	user.CreatedAt = now
 	user.UpdatedAt = now
 
-	err := r.db.QueryRowxContext(
+	err := r.db.Writer(ctx).QueryRowxContext(
 		ctx,
 		query,
 		user.Email,
@@ -54,71 +99,94 @@ func (r *repository) Create(ctx context.Context, user *User) error {
 		user.FirstName,
 		user.LastName,
 		user.IsActive,
+		user.EmailVerifiedAt,
 		user.CreatedAt,
 		user.UpdatedAt,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
-		// Check for unique constraint violation (email already exists)
-		if err.Error() == "pq: duplicate key value violates unique constraint \"users_email_key\"" {
-			return ErrUserAlreadyExists
-		}
-		return fmt.Errorf("failed to create user: %w", err)
+		return fmt.Errorf("failed to create user: %w", database.Translate(err, emailConstraint))
 	}
 
 	return nil
 }
 
-// GetByID retrieves a user by their ID
+// GetByID retrieves a user by their ID, deleted users are not found
 func (r *repository) GetByID(ctx context.Context, id int64) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, is_active, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, is_active, email_verified_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var user User
-	err := r.db.GetContext(ctx, &user, query, id)
+	err := r.db.Reader(ctx).GetContext(ctx, &user, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user by id: %w", err)
+		return nil, fmt.Errorf("failed to get user by id: %w", database.Translate(err))
 	}
 
 	return &user, nil
 }
 
-// GetByEmail retrieves a user by their email
+// GetByEmail retrieves a user by their email, deleted users are not found
 func (r *repository) GetByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, is_active, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, is_active, email_verified_at, created_at, updated_at, deleted_at
 		FROM users
-		WHERE email = $1
+		WHERE email = $1 AND deleted_at IS NULL
 	`
 
 	var user User
-	err := r.db.GetContext(ctx, &user, query, email)
+	err := r.db.Reader(ctx).GetContext(ctx, &user, query, email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrUserNotFound
 		}
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
+		return nil, fmt.Errorf("failed to get user by email: %w", database.Translate(err))
 	}
 
 	return &user, nil
 }
 
+// List retrieves a page of the users matching the filter and the number of all matching users
+// Deleted users are never listed
+func (r *repository) List(ctx context.Context, filter ListFilter, query *pagination.Query[*User]) ([]*User, int64, error) {
+	query = query.Filter(filter.filters()...)
+	db := r.db.Reader(ctx)
+
+	where, args := query.Where("deleted_at IS NULL")
+	selectQuery := db.Rebind(`
+		SELECT id, email, password_hash, first_name, last_name, is_active, email_verified_at, created_at, updated_at, deleted_at
+		FROM users` + where + query.OrderBy() + query.Limit())
+
+	users := []*User{}
+	if err := db.SelectContext(ctx, &users, selectQuery, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", database.Translate(err))
+	}
+
+	countWhere, countArgs := query.CountWhere("deleted_at IS NULL")
+
+	var total int64
+	if err := db.GetContext(ctx, &total, db.Rebind(`SELECT COUNT(*) FROM users`+countWhere), countArgs...); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", database.Translate(err))
+	}
+
+	return users, total, nil
+}
+
 // Update updates an existing user
 func (r *repository) Update(ctx context.Context, user *User) error {
 	query := `
 		UPDATE users
-		SET email = $1, password_hash = $2, first_name = $3, last_name = $4, is_active = $5, updated_at = $6
-		WHERE id = $7
+		SET email = $1, password_hash = $2, first_name = $3, last_name = $4, is_active = $5, email_verified_at = $6, updated_at = $7
+		WHERE id = $8 AND deleted_at IS NULL
 	`
 
 	user.UpdatedAt = time.Now().UTC()
-	result, err := r.db.ExecContext(
+	result, err := r.db.Writer(ctx).ExecContext(
 		ctx,
 		query,
 		user.Email,
@@ -126,12 +194,13 @@ func (r *repository) Update(ctx context.Context, user *User) error {
 		user.FirstName,
 		user.LastName,
 		user.IsActive,
+		user.EmailVerifiedAt,
 		user.UpdatedAt,
 		user.ID,
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return fmt.Errorf("failed to update user: %w", database.Translate(err, emailConstraint))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -146,13 +215,13 @@ func (r *repository) Update(ctx context.Context, user *User) error {
 	return nil
 }
 
-// Delete removes a user from the database
+// Delete soft deletes a user, the row is kept until PurgeDeleted removes it
 func (r *repository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM users WHERE id = $1`
+	query := `UPDATE users SET is_active = false, deleted_at = $1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), id)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return fmt.Errorf("failed to delete user: %w", database.Translate(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -166,3 +235,68 @@ func (r *repository) Delete(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+// Erase anonymises a user and removes their personal data from related tables,
+// leaving a soft deleted row without personal data behind
+func (r *repository) Erase(ctx context.Context, id int64) error {
+	return database.InTx(ctx, r.db.Primary(), nil, func(ctx context.Context) error {
+		now := time.Now().UTC()
+		query := `
+			UPDATE users
+			SET email = $1, password_hash = '', first_name = NULL, last_name = NULL, is_active = false,
+				email_verified_at = NULL, updated_at = $2, deleted_at = COALESCE(deleted_at, $2)
+			WHERE id = $3
+		`
+
+		result, err := r.db.Writer(ctx).ExecContext(ctx, query, ErasedEmail(id), now, id)
+		if err != nil {
+			return fmt.Errorf("failed to erase user: %w", database.Translate(err))
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return ErrUserNotFound
+		}
+
+		// Personal data kept by other features
+		relatedQueries := []string{
+			`DELETE FROM user_identities WHERE user_id = $1`,
+			`DELETE FROM sessions WHERE user_id = $1`,
+			`DELETE FROM api_keys WHERE user_id = $1`,
+			`DELETE FROM user_totp WHERE user_id = $1`,
+			`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+			`DELETE FROM password_history WHERE user_id = $1`,
+			`DELETE FROM user_tokens WHERE user_id = $1`,
+			`UPDATE lockout_events SET identifier = '', ip_address = NULL, user_id = NULL WHERE user_id = $1`,
+		}
+		for _, relatedQuery := range relatedQueries {
+			if _, err := r.db.Writer(ctx).ExecContext(ctx, relatedQuery, id); err != nil {
+				return fmt.Errorf("failed to erase user data: %w", database.Translate(err))
+			}
+		}
+
+		return nil
+	})
+}
+
+// PurgeDeleted permanently removes users soft deleted before the given time
+// Related rows are removed by their ON DELETE constraints
+func (r *repository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	result, err := r.db.Writer(ctx).ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", database.Translate(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -1,15 +1,36 @@
 package user
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // User represents a user entity in the system
 type User struct {
-	ID           int64     `db:"id" json:"id"`
-	Email        string    `db:"email" json:"email"`
-	PasswordHash string    `db:"password_hash" json:"-"`
-	FirstName    *string   `db:"first_name" json:"first_name,omitempty"`
-	LastName     *string   `db:"last_name" json:"last_name,omitempty"`
-	IsActive     bool      `db:"is_active" json:"is_active"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	ID              int64      `db:"id" json:"id"`
+	Email           string     `db:"email" json:"email"`
+	PasswordHash    string     `db:"password_hash" json:"-"`
+	FirstName       *string    `db:"first_name" json:"first_name,omitempty"`
+	LastName        *string    `db:"last_name" json:"last_name,omitempty"`
+	IsActive        bool       `db:"is_active" json:"is_active"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+}
+
+// IsEmailVerified reports whether the user confirmed ownership of their email
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsDeleted reports whether the user has been soft deleted
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+// ErasedEmail is the placeholder address of an erased user
+// The reserved .invalid domain guarantees it never belongs to anyone
+func ErasedEmail(id int64) string {
+	return fmt.Sprintf("erased-%d@erased.invalid", id)
 }
@@ -0,0 +1,116 @@
+package usertoken
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found, expired or already used")
+)
+
+// Repository defines the interface for single-use token data operations
+type Repository interface {
+	Create(ctx context.Context, token *UserToken) error
+	GetValid(ctx context.Context, purpose Purpose, tokenHash string) (*UserToken, error)
+	Consume(ctx context.Context, purpose Purpose, tokenHash string) (*UserToken, error)
+	InvalidateForUser(ctx context.Context, userID int64, purpose Purpose) error
+}
+
+// repository implements the Repository interface using sqlx
+type repository struct {
+	db *database.Router
+}
+
+// NewRepository creates a new user token repository
+func NewRepository(db *database.Router) Repository {
+	return &repository{db: db}
+}
+
+// Create inserts a new token
+func (r *repository) Create(ctx context.Context, token *UserToken) error {
+	query := `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	token.CreatedAt = time.Now().UTC()
+
+	err := r.db.Writer(ctx).QueryRowxContext(
+		ctx,
+		query,
+		token.UserID,
+		token.Purpose,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %w", database.Translate(err))
+	}
+
+	return nil
+}
+
+// GetValid retrieves an unused, unexpired token without consuming it
+func (r *repository) GetValid(ctx context.Context, purpose Purpose, tokenHash string) (*UserToken, error) {
+	query := `
+		SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+		FROM user_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+	`
+
+	var token UserToken
+	err := r.db.Writer(ctx).GetContext(ctx, &token, query, tokenHash, purpose, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to get user token: %w", database.Translate(err))
+	}
+
+	return &token, nil
+}
+
+// Consume atomically marks an unused, unexpired token as used and returns it
+func (r *repository) Consume(ctx context.Context, purpose Purpose, tokenHash string) (*UserToken, error) {
+	query := `
+		UPDATE user_tokens
+		SET used_at = $1
+		WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at
+	`
+
+	var token UserToken
+	err := r.db.Writer(ctx).GetContext(ctx, &token, query, time.Now().UTC(), tokenHash, purpose)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to consume user token: %w", database.Translate(err))
+	}
+
+	return &token, nil
+}
+
+// InvalidateForUser marks every outstanding token of the given purpose as used
+func (r *repository) InvalidateForUser(ctx context.Context, userID int64, purpose Purpose) error {
+	query := `
+		UPDATE user_tokens
+		SET used_at = $1
+		WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL
+	`
+
+	if _, err := r.db.Writer(ctx).ExecContext(ctx, query, time.Now().UTC(), userID, purpose); err != nil {
+		return fmt.Errorf("failed to invalidate user tokens: %w", database.Translate(err))
+	}
+
+	return nil
+}
@@ -11,17 +11,7 @@ import (
 
 // NewClient creates a new PostgreSQL database connection using sqlx
 func NewClient(cfg *config.DatabaseConfig) (*sqlx.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host,
-		cfg.Port,
-		cfg.User,
-		cfg.Password,
-		cfg.DBName,
-		cfg.SSLMode,
-	)
-
-	db, err := sqlx.Connect("postgres", dsn)
+	db, err := sqlx.Connect("postgres", GetDSN(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -39,7 +29,7 @@ func NewClient(cfg *config.DatabaseConfig) (*sqlx.DB, error) {
 	return db, nil
 }
 
-// GetDSN returns the database DSN string for goose migrations
+// GetDSN returns the database DSN string
 func GetDSN(cfg *config.DatabaseConfig) string {
 	return fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/metrics"
+)
+
+// skippedPackages are skipped when naming a query after its caller
+var skippedPackages = []string{
+	reflect.TypeOf(instrumentedQuerier{}).PkgPath() + ".",
+	"github.com/jmoiron/sqlx.",
+}
+
+// instrumentedQuerier records the latency of every query in a histogram
+// and logs the queries slower than threshold with the ID of the request
+type instrumentedQuerier struct {
+	Querier
+	database  string
+	threshold time.Duration
+}
+
+func (q *instrumentedQuerier) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	result, err := q.Querier.ExecContext(ctx, query, args...)
+	q.observe(ctx, query, start, err)
+	return result, err
+}
+
+func (q *instrumentedQuerier) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := q.Querier.QueryContext(ctx, query, args...)
+	q.observe(ctx, query, start, err)
+	return rows, err
+}
+
+func (q *instrumentedQuerier) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	start := time.Now()
+	rows, err := q.Querier.QueryxContext(ctx, query, args...)
+	q.observe(ctx, query, start, err)
+	return rows, err
+}
+
+func (q *instrumentedQuerier) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	start := time.Now()
+	row := q.Querier.QueryRowxContext(ctx, query, args...)
+	q.observe(ctx, query, start, row.Err())
+	return row
+}
+
+func (q *instrumentedQuerier) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	start := time.Now()
+	err := q.Querier.GetContext(ctx, dest, query, args...)
+	q.observe(ctx, query, start, err)
+	return err
+}
+
+func (q *instrumentedQuerier) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	start := time.Now()
+	err := q.Querier.SelectContext(ctx, dest, query, args...)
+	q.observe(ctx, query, start, err)
+	return err
+}
+
+// observe records a finished query, a missing row is an expected outcome rather than a failure
+func (q *instrumentedQuerier) observe(ctx context.Context, query string, start time.Time, err error) {
+	duration := time.Since(start)
+	name := callerName()
+
+	metrics.RecordDBQuery(name, q.database, err == nil || errors.Is(err, sql.ErrNoRows), duration)
+
+	if q.threshold <= 0 || duration < q.threshold {
+		return
+	}
+
+	// Arguments are left out, they may hold personal data or secrets
+	logging.FromContext(ctx).
+		WithField("query_name", name).
+		WithField("database", q.database).
+		WithField("duration_ms", duration.Milliseconds()).
+		WithField("query", strings.Join(strings.Fields(query), " ")).
+		Warn("Slow database query")
+}
+
+var (
+	callerNames sync.Map
+
+	// receiverPattern and closurePattern reduce "user.(*repository).Erase.func1" to "user.Erase"
+	receiverPattern = regexp.MustCompile(`\(\*?[^)]*\)\.`)
+	closurePattern  = regexp.MustCompile(`(\.func\d+)+(\.\d+)*$`)
+)
+
+// callerName names a query after the first function outside this package and sqlx running it,
+// e.g. "user.GetByID", which keeps the histogram labels bounded
+func callerName() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !isSkippedFrame(frame.Function) {
+			if name, ok := callerNames.Load(frame.PC); ok {
+				return name.(string)
+			}
+
+			name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+			name = receiverPattern.ReplaceAllString(name, "")
+			name = closurePattern.ReplaceAllString(name, "")
+			callerNames.Store(frame.PC, name)
+			return name
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func isSkippedFrame(function string) bool {
+	for _, prefix := range skippedPackages {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	migrations "github.com/ozaanmetin/go-microservice-starter/db"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+)
+
+// MigrationsDir is the source directory of the embedded migrations, new migrations are created here
+const MigrationsDir = "db/migrations"
+
+// NewMigrator creates a goose provider for the migrations embedded in the binary
+// Every operation holds a Postgres advisory lock so replicas migrating at the same time
+// wait for each other instead of racing
+func NewMigrator(db *sqlx.DB) (*goose.Provider, error) {
+	fsys, err := fs.Sub(migrations.Migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration lock: %w", err)
+	}
+
+	provider, err := goose.NewProvider(
+		goose.DialectPostgres,
+		db.DB,
+		fsys,
+		goose.WithSessionLocker(locker),
+		goose.WithDisableGlobalRegistry(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	return provider, nil
+}
+
+// Migrate applies all pending migrations
+func Migrate(ctx context.Context, db *sqlx.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	results, err := migrator.Up(ctx)
+	for _, result := range results {
+		LogMigrationResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return nil
+}
+
+// LogMigrationResult logs a migration that was applied or rolled back
+func LogMigrationResult(result *goose.MigrationResult) {
+	log := logging.L().
+		WithField("version", result.Source.Version).
+		WithField("migration", filepath.Base(result.Source.Path)).
+		WithField("direction", result.Direction).
+		WithField("duration", result.Duration.String())
+
+	if result.Error != nil {
+		log.WithError(result.Error).Error("Migration failed")
+		return
+	}
+
+	log.Info("Migration applied")
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+)
+
+// SQLSTATE codes translated into typed errors
+// See https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	codeUniqueViolation      = "23505"
+	codeForeignKeyViolation  = "23503"
+	codeNotNullViolation     = "23502"
+	codeCheckViolation       = "23514"
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+var (
+	ErrUniqueViolation      = errors.New("unique constraint violation")
+	ErrForeignKeyViolation  = errors.New("foreign key constraint violation")
+	ErrNotNullViolation     = errors.New("not null constraint violation")
+	ErrCheckViolation       = errors.New("check constraint violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+	ErrDeadlock             = errors.New("deadlock detected")
+)
+
+var kinds = map[pq.ErrorCode]error{
+	codeUniqueViolation:      ErrUniqueViolation,
+	codeForeignKeyViolation:  ErrForeignKeyViolation,
+	codeNotNullViolation:     ErrNotNullViolation,
+	codeCheckViolation:       ErrCheckViolation,
+	codeSerializationFailure: ErrSerializationFailure,
+	codeDeadlockDetected:     ErrDeadlock,
+}
+
+// Constraint maps a violated constraint or unique index to a domain error
+type Constraint struct {
+	Name string
+	Err  error
+}
+
+// Error is a translated Postgres error
+// It matches its Kind, the domain error of the violated constraint if one was mapped,
+// and the driver error with errors.Is and errors.As
+type Error struct {
+	Kind       error
+	Code       string
+	Constraint string
+	Table      string
+	Column     string
+	Mapped     error
+	Err        *pq.Error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Mapped != nil {
+		return e.Mapped.Error()
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Err.Message)
+}
+
+// Unwrap returns the wrapped errors for error wrapping support
+func (e *Error) Unwrap() []error {
+	if e.Mapped != nil {
+		return []error{e.Mapped, e.Kind, e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Translate converts a Postgres error with a known SQLSTATE into an *Error
+// Violations of the given constraints are mapped to their domain errors,
+// any other error is returned unchanged
+func Translate(err error, constraints ...Constraint) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	kind, ok := kinds[pqErr.Code]
+	if !ok {
+		return err
+	}
+
+	translated := &Error{
+		Kind:       kind,
+		Code:       string(pqErr.Code),
+		Constraint: pqErr.Constraint,
+		Table:      pqErr.Table,
+		Column:     pqErr.Column,
+		Err:        pqErr,
+	}
+
+	for _, constraint := range constraints {
+		if constraint.Name == pqErr.Constraint {
+			translated.Mapped = constraint.Err
+			break
+		}
+	}
+
+	return translated
+}
+
+// IsRetryable reports whether the error is a transient conflict between
+// concurrent transactions that may succeed when retried
+func IsRetryable(err error) bool {
+	err = Translate(err)
+	return errors.Is(err, ErrSerializationFailure) || errors.Is(err, ErrDeadlock)
+}
+
+// ToServiceError maps a database error to a ServiceError by its SQLSTATE
+// It is the fallback for errors that no feature mapped to a more specific
+// response and returns nil for errors that do not come from Postgres
+func ToServiceError(err error) *appErrors.ServiceError {
+	var dbErr *Error
+	if !errors.As(Translate(err), &dbErr) {
+		return nil
+	}
+
+	switch dbErr.Kind {
+	case ErrUniqueViolation:
+		return appErrors.NewConflictError("Resource already exists", err)
+	case ErrForeignKeyViolation:
+		return appErrors.NewConflictError("Referenced resource does not exist or is still in use", err)
+	case ErrNotNullViolation, ErrCheckViolation:
+		return appErrors.NewValidationError("Invalid data", err)
+	case ErrSerializationFailure, ErrDeadlock:
+		return appErrors.NewServiceUnavailableError("Concurrent update conflict, please retry", err).
+			WithHeader("Retry-After", "1")
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/metrics"
+)
+
+// Replica selection policies
+const (
+	PolicyRoundRobin = "round_robin"
+	PolicyLeastConn  = "least_conn"
+)
+
+// replicaPingTimeout bounds a single replica health check
+const replicaPingTimeout = 2 * time.Second
+
+// primaryName labels the primary in query metrics and logs
+const primaryName = "primary"
+
+type primaryContextKey struct{}
+
+// WithPrimary marks ctx so reads go to the primary instead of a replica
+// Use it when a read must observe a write made just before, or when the
+// result is written back, since replicas may lag behind the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// RequiresPrimary reports whether reads on ctx must observe the latest data,
+// because they run in a transaction or ctx was marked with WithPrimary
+// Caches in front of repositories should be bypassed for such reads
+func RequiresPrimary(ctx context.Context) bool {
+	if _, ok := TxFromContext(ctx); ok {
+		return true
+	}
+	forced, _ := ctx.Value(primaryContextKey{}).(bool)
+	return forced
+}
+
+type replica struct {
+	name    string
+	db      *sqlx.DB
+	healthy atomic.Bool
+}
+
+// Router sends writes to the primary and read-only queries to healthy replicas
+// Without healthy replicas reads fall back to the primary. Every query run through
+// the router is timed and logged when it takes longer than slowQuery
+type Router struct {
+	primary   *sqlx.DB
+	replicas  []*replica
+	policy    string
+	next      atomic.Uint64
+	slowQuery time.Duration
+}
+
+// NewRouter creates a router for the primary and the replicas configured in cfg
+// Replicas are connected lazily, one that is down only serves reads once a health check passes
+func NewRouter(primary *sqlx.DB, cfg *config.DatabaseConfig) (*Router, error) {
+	policy := cfg.ReplicaRouting.Policy
+	switch policy {
+	case "":
+		policy = PolicyRoundRobin
+	case PolicyRoundRobin, PolicyLeastConn:
+	default:
+		return nil, fmt.Errorf("unsupported replica routing policy %q", policy)
+	}
+
+	router := &Router{
+		primary:   primary,
+		policy:    policy,
+		slowQuery: cfg.SlowQueryThreshold,
+	}
+
+	for _, replicaCfg := range cfg.Replicas {
+		replicaDBCfg := *cfg
+		replicaDBCfg.Host = replicaCfg.Host
+		replicaDBCfg.Port = replicaCfg.Port
+
+		db, err := sqlx.Open("postgres", GetDSN(&replicaDBCfg))
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("failed to open replica %s: %w", replicaCfg.Host, err)
+		}
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+		router.replicas = append(router.replicas, &replica{
+			name: fmt.Sprintf("%s:%d", replicaCfg.Host, replicaCfg.Port),
+			db:   db,
+		})
+	}
+
+	router.CheckReplicas(context.Background())
+
+	return router, nil
+}
+
+// Primary returns the primary database
+func (r *Router) Primary() *sqlx.DB {
+	return r.primary
+}
+
+// Writer returns the transaction in ctx, if any, or the primary
+func (r *Router) Writer(ctx context.Context) Querier {
+	return r.instrument(Conn(ctx, r.primary), primaryName)
+}
+
+// Reader returns a connection for a read-only query
+// Reads inside a transaction or on a context marked with WithPrimary use the primary
+func (r *Router) Reader(ctx context.Context) Querier {
+	if RequiresPrimary(ctx) {
+		return r.Writer(ctx)
+	}
+
+	if replica := r.pick(); replica != nil {
+		return r.instrument(replica.db, replica.name)
+	}
+
+	return r.instrument(r.primary, primaryName)
+}
+
+func (r *Router) instrument(q Querier, database string) Querier {
+	return &instrumentedQuerier{Querier: q, database: database, threshold: r.slowQuery}
+}
+
+// pick selects a healthy replica according to the policy, nil if there is none
+func (r *Router) pick() *replica {
+	healthy := make([]*replica, 0, len(r.replicas))
+	for _, replica := range r.replicas {
+		if replica.healthy.Load() {
+			healthy = append(healthy, replica)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	if r.policy == PolicyLeastConn {
+		least := healthy[0]
+		for _, replica := range healthy[1:] {
+			if replica.db.Stats().InUse < least.db.Stats().InUse {
+				least = replica
+			}
+		}
+		return least
+	}
+
+	return healthy[r.next.Add(1)%uint64(len(healthy))]
+}
+
+// CheckReplicas pings every replica and updates whether it receives reads
+func (r *Router) CheckReplicas(ctx context.Context) {
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := replica.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) == healthy {
+			continue
+		}
+
+		if healthy {
+			logging.L().WithField("replica", replica.name).Info("Database replica is healthy")
+		} else {
+			logging.L().WithError(err).WithField("replica", replica.name).Warn("Database replica is unhealthy, reading from other databases")
+		}
+	}
+}
+
+// WatchReplicas checks the health of the replicas every interval
+func (r *Router) WatchReplicas(interval time.Duration) {
+	if len(r.replicas) == 0 || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.CheckReplicas(context.Background())
+	}
+}
+
+// RegisterMetrics exports the connection pool statistics of the primary and the replicas
+func (r *Router) RegisterMetrics() error {
+	if err := metrics.RegisterDBStats(primaryName, r.primary.DB); err != nil {
+		return fmt.Errorf("failed to register primary pool metrics: %w", err)
+	}
+
+	for _, replica := range r.replicas {
+		if err := metrics.RegisterDBStats(replica.name, replica.db.DB); err != nil {
+			return fmt.Errorf("failed to register replica %s pool metrics: %w", replica.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the replica connections, the primary is closed by its owner
+func (r *Router) Close() error {
+	for _, replica := range r.replicas {
+		replica.db.Close()
+	}
+	return nil
+}
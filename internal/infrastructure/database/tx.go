@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ozaanmetin/go-microservice-starter/internal/config"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+)
+
+// Querier is implemented by both *sqlx.DB and *sqlx.Tx so repositories can run
+// the same queries inside and outside of a transaction
+type Querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+type txContextKey struct{}
+
+type txHooksContextKey struct{}
+
+// txHooks collects the functions to run once the transaction has committed
+type txHooks struct {
+	afterCommit []func()
+}
+
+// Conn returns the transaction carried by ctx, or db when there is none
+// Repositories use it for every query so they join the caller's unit of work
+func Conn(ctx context.Context, db *sqlx.DB) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// TxFromContext returns the transaction carried by ctx
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*sqlx.Tx)
+	return tx, ok
+}
+
+// InTx runs fn in a transaction on db and commits it when fn succeeds
+// When ctx already carries a transaction fn joins it and opts are ignored.
+// InTx does not retry, use TxManager.WithinTx for units of work that can be repeated
+func InTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", Translate(err))
+	}
+
+	// Roll back on panics too, the panic is re-raised for the recover middleware
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	hooks := &txHooks{}
+	txCtx := context.WithValue(ctx, txContextKey{}, tx)
+	txCtx = context.WithValue(txCtx, txHooksContextKey{}, hooks)
+
+	if err := fn(txCtx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", Translate(err))
+	}
+
+	for _, hook := range hooks.afterCommit {
+		hook()
+	}
+
+	return nil
+}
+
+// AfterCommit runs fn once the transaction carried by ctx has committed, or right
+// away when there is none. It is skipped when the transaction rolls back
+// Use it for side effects that must not be observed before the data is visible,
+// e.g. invalidating a cache that concurrent readers could refill with old rows
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(txHooksContextKey{}).(*txHooks); ok {
+		hooks.afterCommit = append(hooks.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// TxOption customises a single WithinTx call
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	isolation  sql.IsolationLevel
+	readOnly   bool
+	maxRetries int
+}
+
+// WithIsolation runs the transaction with the given isolation level
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *txOptions) {
+		o.isolation = level
+	}
+}
+
+// ReadOnly runs the transaction in read-only mode
+func ReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.readOnly = true
+	}
+}
+
+// WithMaxRetries overrides how often the transaction is retried after a
+// serialization failure or deadlock, 0 disables retries
+func WithMaxRetries(maxRetries int) TxOption {
+	return func(o *txOptions) {
+		o.maxRetries = maxRetries
+	}
+}
+
+// TxManager runs units of work spanning several repositories in one transaction
+type TxManager struct {
+	db         *sqlx.DB
+	isolation  sql.IsolationLevel
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// NewTxManager creates a transaction manager using the configured defaults
+func NewTxManager(db *sqlx.DB, cfg *config.TransactionConfig) (*TxManager, error) {
+	isolation, err := ParseIsolationLevel(cfg.Isolation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxManager{
+		db:         db,
+		isolation:  isolation,
+		maxRetries: cfg.MaxRetries,
+		retryDelay: cfg.RetryDelay,
+	}, nil
+}
+
+// WithinTx runs fn in a transaction carried by the context passed to it
+// Repositories called with that context use the transaction. The transaction
+// commits when fn returns nil and rolls back otherwise. Serialization failures
+// and deadlocks roll back and run fn again, so fn must be safe to repeat.
+// Nested calls join the outer transaction and leave retrying to it
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	o := txOptions{
+		isolation:  m.isolation,
+		maxRetries: m.maxRetries,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	txOpts := &sql.TxOptions{Isolation: o.isolation, ReadOnly: o.readOnly}
+
+	for attempt := 1; ; attempt++ {
+		err := InTx(ctx, m.db, txOpts, fn)
+		if err == nil || !IsRetryable(err) || attempt > o.maxRetries {
+			return err
+		}
+
+		logging.L().
+			WithError(err).
+			WithField("attempt", attempt).
+			Warn("Retrying transaction after a concurrent update conflict")
+
+		// Back off linearly so competing transactions do not collide again right away
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * m.retryDelay):
+		}
+	}
+}
+
+// ParseIsolationLevel converts a configured isolation level name, e.g.
+// "read_committed" or "serializable", to its sql.IsolationLevel
+// An empty name selects the database default
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	switch name {
+	case "", "default":
+		return sql.LevelDefault, nil
+	case "read_uncommitted":
+		return sql.LevelReadUncommitted, nil
+	case "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unsupported transaction isolation level %q", name)
+	}
+}
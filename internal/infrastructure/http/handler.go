@@ -5,7 +5,9 @@ import (
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/http/middlewares"
 	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
 )
 
 // Request is a marker interface for all request types
@@ -19,13 +21,18 @@ type StatusCodeProvider interface {
 	StatusCode() int
 }
 
+// HeadersProvider allows responses to set additional HTTP headers
+type HeadersProvider interface {
+	Headers() map[string]string
+}
+
 // HandlerInterface defines the generic handler interface for business logic
 type HandlerInterface[R Request, Res Response] interface {
 	Handle(ctx context.Context, req *R) (*Res, error)
 }
 
 // AdaptHandler adapts a generic HandlerInterface to Fiber's handler signature
-// It handles request parsing (body, params, query, headers) and error responses
+// It handles request parsing (body, params, query, headers), validation and error responses
 func AdaptHandler[R Request, Res Response](handler HandlerInterface[R, Res]) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req R
@@ -59,6 +66,20 @@ func AdaptHandler[R Request, Res Response](handler HandlerInterface[R, Res]) fib
 			ctx = context.WithValue(ctx, "user", userClaims)
 		}
 
+		// Transfer the client IP and user agent so business logic can apply
+		// per-client policies and record where requests come from
+		ctx = context.WithValue(ctx, middlewares.ClientIPContextKey, c.IP())
+		ctx = context.WithValue(ctx, middlewares.UserAgentContextKey, c.Get(fiber.HeaderUserAgent))
+
+		// Transfer the request ID so logs written by business logic, e.g. slow
+		// database queries, can be correlated with the request's access log
+		ctx = logging.WithRequestID(ctx, middlewares.GetRequestID(c))
+
+		// Validate struct tags and custom rules before reaching business logic
+		if err := validateRequest(ctx, &req); err != nil {
+			return err
+		}
+
 		// Call business handler
 		res, err := handler.Handle(ctx, &req)
 
@@ -73,6 +94,13 @@ func AdaptHandler[R Request, Res Response](handler HandlerInterface[R, Res]) fib
 			return appErrors.NewInternalServerError(err)
 		}
 
+		// Check if response implements HeadersProvider
+		if provider, ok := any(res).(HeadersProvider); ok {
+			for key, value := range provider.Headers() {
+				c.Set(key, value)
+			}
+		}
+
 		// Check if response implements StatusCodeProvider
 		if provider, ok := any(res).(StatusCodeProvider); ok {
 			return c.Status(provider.StatusCode()).JSON(res)
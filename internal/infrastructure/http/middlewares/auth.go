@@ -2,57 +2,141 @@ package middlewares
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
 	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
 )
 
 // UserContextKey is the key used to store user info in context
 const UserContextKey = "user"
 
-// AuthMiddleware creates a JWT authentication middleware
-// This middleware validates JWT tokens and stores claims in the request context
-func AuthMiddleware(jwtManager *pkgJWT.Manager) fiber.Handler {
-	return func(c *fiber.Ctx) error {
+// APIKeyHeader is the header service-to-service callers send their API key in
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries
+	// none of its credentials, letting the next authenticator in the chain try
+	ErrNoCredentials              = errors.New("missing credentials")
+	ErrInvalidAuthorizationHeader = errors.New("invalid authorization header format")
+	ErrInvalidCredentials         = errors.New("invalid credentials")
+)
+
+// Authenticator resolves the principal of a request
+type Authenticator interface {
+	Authenticate(c *fiber.Ctx) (*pkgJWT.Claims, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface
+type AuthenticatorFunc func(c *fiber.Ctx) (*pkgJWT.Claims, error)
+
+func (f AuthenticatorFunc) Authenticate(c *fiber.Ctx) (*pkgJWT.Claims, error) {
+	return f(c)
+}
+
+// APIKeyResolver resolves an API key to the claims of its owner
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (*pkgJWT.Claims, error)
+}
+
+// JWTAuthenticator authenticates requests with a Bearer access token
+func JWTAuthenticator(jwtManager *pkgJWT.Manager) Authenticator {
+	return AuthenticatorFunc(func(c *fiber.Ctx) (*pkgJWT.Claims, error) {
 		// Get Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"code":    "unauthorized",
-				"message": "Missing authorization header",
-			})
+			return nil, ErrNoCredentials
 		}
 
 		// Check Bearer token format
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"code":    "unauthorized",
-				"message": "Invalid authorization header format",
-			})
+			return nil, ErrInvalidAuthorizationHeader
 		}
 
-		tokenString := parts[1]
+		// Validate access token and check it has not been revoked
+		claims, err := jwtManager.ValidateAccessToken(c.UserContext(), parts[1])
+		if err != nil {
+			return nil, ErrInvalidCredentials
+		}
+
+		return claims, nil
+	})
+}
 
-		// Validate access token
-		claims, err := jwtManager.ValidateAccessToken(tokenString)
+// APIKeyAuthenticator authenticates requests with an API key in the X-API-Key header
+func APIKeyAuthenticator(resolver APIKeyResolver) Authenticator {
+	return AuthenticatorFunc(func(c *fiber.Ctx) (*pkgJWT.Claims, error) {
+		key := c.Get(APIKeyHeader)
+		if key == "" {
+			return nil, ErrNoCredentials
+		}
+
+		claims, err := resolver.ResolveAPIKey(c.UserContext(), key)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"code":    "unauthorized",
-				"message": "Invalid or expired token",
-			})
+			return nil, err
 		}
 
-		// Store claims in Fiber context (for framework-level access)
-		c.Locals(UserContextKey, claims)
+		return claims, nil
+	})
+}
+
+// Authenticate creates a middleware that tries the authenticators in order and
+// stores the claims of the first one that recognises the request's credentials.
+// Credentials that are present but invalid reject the request right away.
+// Authenticators report bad credentials with ErrInvalidCredentials, any other
+// error is treated as an internal failure
+func Authenticate(authenticators ...Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, authenticator := range authenticators {
+			claims, err := authenticator.Authenticate(c)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrInvalidAuthorizationHeader) {
+				return unauthorized(c, err)
+			}
+			if err != nil {
+				return appErrors.NewInternalServerError(err)
+			}
 
-		return c.Next()
+			// Store claims in Fiber context (for framework-level access)
+			c.Locals(UserContextKey, claims)
+
+			return c.Next()
+		}
+
+		return unauthorized(c, ErrNoCredentials)
 	}
 }
 
+// AuthMiddleware creates a JWT authentication middleware
+// This middleware validates JWT tokens and stores claims in the request context
+func AuthMiddleware(jwtManager *pkgJWT.Manager) fiber.Handler {
+	return Authenticate(JWTAuthenticator(jwtManager))
+}
+
+// unauthorized writes the 401 response for a failed authentication
+func unauthorized(c *fiber.Ctx, err error) error {
+	message := "Invalid or expired credentials"
+	switch {
+	case errors.Is(err, ErrNoCredentials):
+		message = "Missing authorization header or API key"
+	case errors.Is(err, ErrInvalidAuthorizationHeader):
+		message = "Invalid authorization header format"
+	}
+
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"code":    "unauthorized",
+		"message": message,
+	})
+}
+
 // GetUserFromContext retrieves user claims from standard context.Context
-// This is the framework-agnostic way to access authenticated user information
+// This is the framework-agnostic way to access authenticated user information.
+// Claims of API key callers have TokenType pkgJWT.APIKeyToken
 func GetUserFromContext(ctx context.Context) (*pkgJWT.Claims, bool) {
 	claims, ok := ctx.Value(UserContextKey).(*pkgJWT.Claims)
 	return claims, ok
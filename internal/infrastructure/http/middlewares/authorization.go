@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+)
+
+// RequireRoles allows the request if the authenticated user has at least one of the roles
+// Must be registered after AuthMiddleware
+func RequireRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals(UserContextKey).(*pkgJWT.Claims)
+		if !ok {
+			return appErrors.NewUnauthorizedError("User not authenticated", nil)
+		}
+
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				return c.Next()
+			}
+		}
+
+		return appErrors.NewForbiddenError("Insufficient role", nil).
+			AddDetail("required_roles", roles)
+	}
+}
+
+// RequirePermission allows the request only if the authenticated user has every permission
+// Must be registered after AuthMiddleware
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals(UserContextKey).(*pkgJWT.Claims)
+		if !ok {
+			return appErrors.NewUnauthorizedError("User not authenticated", nil)
+		}
+
+		var missing []string
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				missing = append(missing, permission)
+			}
+		}
+
+		if len(missing) > 0 {
+			return appErrors.NewForbiddenError("Missing permission: "+strings.Join(missing, ", "), nil).
+				AddDetail("missing_permissions", missing)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireUserToken rejects API key callers, guard credential management routes with it
+// so a leaked key cannot take over the account it belongs to, whatever its scopes
+// Must be registered after AuthMiddleware
+func RequireUserToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := c.Locals(UserContextKey).(*pkgJWT.Claims)
+		if !ok {
+			return appErrors.NewUnauthorizedError("User not authenticated", nil)
+		}
+
+		if claims.TokenType == pkgJWT.APIKeyToken {
+			return appErrors.NewForbiddenError("This action cannot be performed with an API key", nil)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,12 @@
+package middlewares
+
+import "context"
+
+// ClientIPContextKey is the key used to store the client IP address in context
+const ClientIPContextKey = "client_ip"
+
+// GetClientIPFromContext retrieves the client IP address from standard context.Context
+func GetClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ClientIPContextKey).(string)
+	return ip
+}
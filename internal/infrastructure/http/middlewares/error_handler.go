@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
 	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
 )
 
@@ -21,8 +22,18 @@ func ServiceErrorErrorHandler() fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
 		// Check if it's a ServiceError
 		var serviceErr *appErrors.ServiceError
+		isServiceErr := errors.As(err, &serviceErr)
+
+		// Database errors no feature mapped, e.g. wrapped as internal errors,
+		// get a status matching their SQLSTATE instead of a generic 500
+		if !isServiceErr || serviceErr.StatusCode == fiber.StatusInternalServerError {
+			if dbErr := database.ToServiceError(err); dbErr != nil {
+				serviceErr = dbErr
+				isServiceErr = true
+			}
+		}
 
-		if !errors.As(err, &serviceErr) {
+		if !isServiceErr {
 			// Not a ServiceError - use Fiber's default handler
 			return defaultHandler(c, err)
 		}
@@ -41,6 +52,11 @@ func ServiceErrorErrorHandler() fiber.ErrorHandler {
 		if response.Details == nil {
 			response.Details = make(map[string]interface{})
 		}
+
+		for key, value := range serviceErr.Headers {
+			c.Set(key, value)
+		}
+
 		return c.Status(statusCode).JSON(response)
 	}
 }
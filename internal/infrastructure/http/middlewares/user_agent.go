@@ -0,0 +1,12 @@
+package middlewares
+
+import "context"
+
+// UserAgentContextKey is the key used to store the client's User-Agent header in context
+const UserAgentContextKey = "user_agent"
+
+// GetUserAgentFromContext retrieves the client's User-Agent from standard context.Context
+func GetUserAgentFromContext(ctx context.Context) string {
+	userAgent, _ := ctx.Value(UserAgentContextKey).(string)
+	return userAgent
+}
@@ -72,6 +72,11 @@ func (s *Server) Put(path string, handler fiber.Handler, mws ...fiber.Handler) {
 	s.app.Put(path, handlers...)
 }
 
+func (s *Server) Patch(path string, handler fiber.Handler, mws ...fiber.Handler) {
+	handlers := append(mws, handler)
+	s.app.Patch(path, handlers...)
+}
+
 func (s *Server) Delete(path string, handler fiber.Handler, mws ...fiber.Handler) {
 	handlers := append(mws, handler)
 	s.app.Delete(path, handlers...)
@@ -103,11 +108,21 @@ func (g *RouteGroup) Put(path string, handler fiber.Handler, mws ...fiber.Handle
 	g.router.Put(path, handlers...)
 }
 
+func (g *RouteGroup) Patch(path string, handler fiber.Handler, mws ...fiber.Handler) {
+	handlers := append(mws, handler)
+	g.router.Patch(path, handlers...)
+}
+
 func (g *RouteGroup) Delete(path string, handler fiber.Handler, mws ...fiber.Handler) {
 	handlers := append(mws, handler)
 	g.router.Delete(path, handlers...)
 }
 
+// Group creates a nested group, e.g. an admin-only group inside /api
+func (g *RouteGroup) Group(prefix string, mws ...fiber.Handler) *RouteGroup {
+	return &RouteGroup{router: g.router.Group(prefix, mws...)}
+}
+
 // ---- Middlewares
 
 func (s *Server) setupMiddlewares() {
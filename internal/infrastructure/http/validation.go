@@ -0,0 +1,248 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+)
+
+// Validatable allows request types to register custom validation rules.
+// Validate is called by AdaptHandler after the struct tag rules have passed.
+type Validatable interface {
+	Validate(ctx context.Context) error
+}
+
+// FieldError describes a single failing field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors can be returned from Validatable.Validate to report
+// field-level failures in the same format as the struct tag rules
+type ValidationErrors []FieldError
+
+// Error implements the error interface
+func (v ValidationErrors) Error() string {
+	parts := make([]string, 0, len(v))
+	for _, fe := range v {
+		parts = append(parts, fmt.Sprintf("%s: %s", fe.Field, fe.Message))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// validate is shared by all handlers, validator caches struct metadata internally
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report field names the way clients send them instead of Go field names
+	v.RegisterTagNameFunc(fieldName)
+
+	return v
+}
+
+// fieldName returns the name clients use for a request field, taken from its
+// json, query, params or reqHeader tag; empty for fields excluded with "-"
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "params", "reqHeader"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// validateRequest runs struct tag validation and, if the request implements
+// Validatable, its custom rules. Failures are returned as a 422 ServiceError.
+func validateRequest(ctx context.Context, req any) error {
+	if isStruct(req) {
+		if err := validate.StructCtx(ctx, req); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				return newValidationServiceError(toFieldErrors(validationErrs, reflect.TypeOf(req)))
+			}
+			return appErrors.NewInternalServerError(err)
+		}
+	}
+
+	custom, ok := req.(Validatable)
+	if !ok {
+		return nil
+	}
+
+	err := custom.Validate(ctx)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		return newValidationServiceError(fieldErrs)
+	}
+
+	var serviceErr *appErrors.ServiceError
+	if errors.As(err, &serviceErr) {
+		return err
+	}
+
+	return appErrors.NewValidationError(err.Error(), err)
+}
+
+func newValidationServiceError(fieldErrs ValidationErrors) *appErrors.ServiceError {
+	serviceErr := appErrors.NewValidationError("Request validation failed", fieldErrs)
+	for _, fe := range fieldErrs {
+		serviceErr.AddDetail(fe.Field, map[string]string{
+			"rule":    fe.Rule,
+			"message": fe.Message,
+		})
+	}
+	return serviceErr
+}
+
+func toFieldErrors(validationErrs validator.ValidationErrors, root reflect.Type) ValidationErrors {
+	fieldErrs := make(ValidationErrors, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   fieldPath(fe, root),
+			Rule:    fe.Tag(),
+			Message: fieldMessage(fe, root),
+		})
+	}
+	return fieldErrs
+}
+
+// fieldPath strips the root struct name and embedded structs from the namespace so
+// nested fields are reported as "address.city" rather than "Request.address.city"
+func fieldPath(fe validator.FieldError, root reflect.Type) string {
+	names := strings.Split(fe.Namespace(), ".")
+	goNames := strings.Split(fe.StructNamespace(), ".")
+	if len(names) < 2 || len(names) != len(goNames) {
+		return fe.Field()
+	}
+
+	path := make([]string, 0, len(names)-1)
+	t := root
+	for i := 1; i < len(names); i++ {
+		t = elemType(t)
+		if t.Kind() == reflect.Struct {
+			goName, _, _ := strings.Cut(goNames[i], "[")
+			if field, ok := t.FieldByName(goName); ok {
+				t = field.Type
+				// Fields of embedded structs are parsed as fields of the request itself
+				if field.Anonymous {
+					continue
+				}
+			}
+		}
+		path = append(path, names[i])
+	}
+
+	return strings.Join(path, ".")
+}
+
+// elemType dereferences pointers and collection types down to their element type
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// siblingFieldName returns the client-facing name of the Go field goName in the
+// struct holding the failed field, e.g. the field referenced by eqfield
+func siblingFieldName(fe validator.FieldError, root reflect.Type, goName string) string {
+	goNames := strings.Split(fe.StructNamespace(), ".")
+	if len(goNames) < 2 {
+		return goName
+	}
+
+	t := root
+	for _, name := range goNames[1 : len(goNames)-1] {
+		t = elemType(t)
+		if t.Kind() != reflect.Struct {
+			return goName
+		}
+		name, _, _ = strings.Cut(name, "[")
+		field, ok := t.FieldByName(name)
+		if !ok {
+			return goName
+		}
+		t = field.Type
+	}
+
+	if t = elemType(t); t.Kind() == reflect.Struct {
+		if field, ok := t.FieldByName(goName); ok {
+			if name := fieldName(field); name != "" {
+				return name
+			}
+		}
+	}
+	return goName
+}
+
+// fieldMessage builds a human-readable message for the failed rule
+func fieldMessage(fe validator.FieldError, root reflect.Type) string {
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required", "required_with", "required_without", "required_if":
+		return "This field is required"
+	case "email":
+		return "Must be a valid email address"
+	case "url", "http_url":
+		return "Must be a valid URL"
+	case "uuid", "uuid4":
+		return "Must be a valid UUID"
+	case "oneof":
+		return fmt.Sprintf("Must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "min", "gte":
+		if isString {
+			return fmt.Sprintf("Must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("Must be at least %s", fe.Param())
+	case "max", "lte":
+		if isString {
+			return fmt.Sprintf("Must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("Must be at most %s", fe.Param())
+	case "len":
+		if isString {
+			return fmt.Sprintf("Must be exactly %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("Must contain exactly %s items", fe.Param())
+	case "gt":
+		return fmt.Sprintf("Must be greater than %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("Must be less than %s", fe.Param())
+	case "eqfield":
+		return fmt.Sprintf("Must match %s", siblingFieldName(fe, root, fe.Param()))
+	case "nefield":
+		return fmt.Sprintf("Must differ from %s", siblingFieldName(fe, root, fe.Param()))
+	default:
+		return fmt.Sprintf("Failed on the '%s' rule", fe.Tag())
+	}
+}
+
+func isStruct(v any) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct
+}
@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/lockout"
+)
+
+const (
+	loginFailuresKeyPrefix = "login:failures:"
+	loginLockKeyPrefix     = "login:lock:"
+	loginStrikesKeyPrefix  = "login:strikes:"
+)
+
+// recordFailureScript counts a failed attempt within the attempt window and,
+// once the limit is reached, locks the subject for an exponentially growing
+// duration based on how many times it was locked before
+//
+// KEYS: failures, lock, strikes
+// ARGV: attempt window ms, max attempts, base lock ms, max lock ms, strikes ttl ms
+// Returns: {attempts, lock ms}
+var recordFailureScript = redis.NewScript(`
+local attempts = redis.call("INCR", KEYS[1])
+if attempts == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+if attempts < tonumber(ARGV[2]) then
+	return {attempts, 0}
+end
+
+local strikes = redis.call("INCR", KEYS[3])
+redis.call("PEXPIRE", KEYS[3], ARGV[5])
+
+local lockMs = tonumber(ARGV[4])
+if strikes <= 32 then
+	lockMs = math.min(tonumber(ARGV[3]) * 2 ^ (strikes - 1), lockMs)
+end
+lockMs = math.floor(lockMs)
+
+redis.call("SET", KEYS[2], strikes, "PX", lockMs)
+redis.call("DEL", KEYS[1])
+return {attempts, lockMs}
+`)
+
+// LoginAttemptTracker implements lockout.Tracker on top of Redis
+// Failed attempts, active locks and previous lockouts are kept in expiring keys,
+// so locks are lifted automatically once their TTL elapses
+type LoginAttemptTracker struct {
+	client *redis.Client
+}
+
+// NewLoginAttemptTracker creates a new Redis backed login attempt tracker
+func NewLoginAttemptTracker(client *redis.Client) *LoginAttemptTracker {
+	return &LoginAttemptTracker{client: client}
+}
+
+// LockedFor returns the longest remaining lock among the given subjects
+func (t *LoginAttemptTracker) LockedFor(ctx context.Context, subjects ...lockout.Subject) (time.Duration, error) {
+	pipe := t.client.Pipeline()
+	cmds := make([]*redis.DurationCmd, 0, len(subjects))
+	for _, subject := range subjects {
+		if subject.Identifier == "" {
+			continue
+		}
+		cmds = append(cmds, pipe.PTTL(ctx, loginLockKey(subject)))
+	}
+	if len(cmds) == 0 {
+		return 0, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	var longest time.Duration
+	for _, cmd := range cmds {
+		// Missing keys report a negative TTL
+		if ttl := cmd.Val(); ttl > longest {
+			longest = ttl
+		}
+	}
+	return longest, nil
+}
+
+// RecordFailure counts a failed attempt and locks the subject once it reaches the policy's limit
+func (t *LoginAttemptTracker) RecordFailure(ctx context.Context, subject lockout.Subject, policy lockout.Policy) (lockout.Failure, error) {
+	if subject.Identifier == "" || policy.MaxAttempts <= 0 || policy.BaseDuration <= 0 {
+		return lockout.Failure{}, nil
+	}
+
+	result, err := recordFailureScript.Run(
+		ctx,
+		t.client,
+		[]string{loginFailuresKey(subject), loginLockKey(subject), loginStrikesKey(subject)},
+		policy.AttemptWindow.Milliseconds(),
+		policy.MaxAttempts,
+		policy.BaseDuration.Milliseconds(),
+		policy.MaxDuration.Milliseconds(),
+		policy.ResetAfter.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return lockout.Failure{}, err
+	}
+
+	return lockout.Failure{
+		Attempts:  int(result[0]),
+		LockedFor: time.Duration(result[1]) * time.Millisecond,
+	}, nil
+}
+
+// Reset forgets the failed attempts and previous lockouts of the subject
+func (t *LoginAttemptTracker) Reset(ctx context.Context, subject lockout.Subject) error {
+	if subject.Identifier == "" {
+		return nil
+	}
+	return t.client.Del(ctx, loginFailuresKey(subject), loginStrikesKey(subject)).Err()
+}
+
+func loginFailuresKey(subject lockout.Subject) string {
+	return loginFailuresKeyPrefix + subjectKey(subject)
+}
+
+func loginLockKey(subject lockout.Subject) string {
+	return loginLockKeyPrefix + subjectKey(subject)
+}
+
+func loginStrikesKey(subject lockout.Subject) string {
+	return loginStrikesKeyPrefix + subjectKey(subject)
+}
+
+func subjectKey(subject lockout.Subject) string {
+	return string(subject.Scope) + ":" + strings.ToLower(subject.Identifier)
+}
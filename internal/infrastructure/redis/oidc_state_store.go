@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc"
+)
+
+const oidcStateKeyPrefix = "oidc:state:"
+
+// OIDCStateStore implements oidc.StateStore on top of Redis
+type OIDCStateStore struct {
+	client *redis.Client
+}
+
+// NewOIDCStateStore creates a new Redis backed OIDC login state store
+func NewOIDCStateStore(client *redis.Client) *OIDCStateStore {
+	return &OIDCStateStore{client: client}
+}
+
+// Save stores the state until ttl elapses
+func (s *OIDCStateStore) Save(ctx context.Context, key string, state *oidc.State, ttl time.Duration) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, oidcStateKeyPrefix+key, data, ttl).Err()
+}
+
+// Take atomically returns and deletes the state
+func (s *OIDCStateStore) Take(ctx context.Context, key string) (*oidc.State, error) {
+	data, err := s.client.GetDel(ctx, oidcStateKeyPrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, oidc.ErrStateNotFound
+		}
+		return nil, err
+	}
+
+	var state oidc.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
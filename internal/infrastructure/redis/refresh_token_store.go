@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const refreshFamilyKeyPrefix = "jwt:refresh_family:"
+
+// rotateScript swaps the latest refresh token ID of a family only if the
+// presented ID is still the latest one, so concurrent rotations cannot both win
+var rotateScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// RefreshTokenStore implements jwt.RefreshTokenStore on top of Redis
+// Each session (token family) maps to the ID of its latest refresh token
+type RefreshTokenStore struct {
+	client *redis.Client
+}
+
+// NewRefreshTokenStore creates a new Redis backed refresh token store
+func NewRefreshTokenStore(client *redis.Client) *RefreshTokenStore {
+	return &RefreshTokenStore{client: client}
+}
+
+// Save stores tokenID as the latest refresh token of the session
+func (s *RefreshTokenStore) Save(ctx context.Context, sessionID, tokenID string, ttl time.Duration) error {
+	return s.client.Set(ctx, refreshFamilyKey(sessionID), tokenID, ttl).Err()
+}
+
+// Rotate replaces currentID with nextID if currentID is the latest refresh token
+func (s *RefreshTokenStore) Rotate(ctx context.Context, sessionID, currentID, nextID string, ttl time.Duration) (bool, error) {
+	rotated, err := rotateScript.Run(
+		ctx,
+		s.client,
+		[]string{refreshFamilyKey(sessionID)},
+		currentID,
+		nextID,
+		ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return rotated == 1, nil
+}
+
+// Delete forgets the session's refresh token family
+func (s *RefreshTokenStore) Delete(ctx context.Context, sessionID string) error {
+	return s.client.Del(ctx, refreshFamilyKey(sessionID)).Err()
+}
+
+func refreshFamilyKey(sessionID string) string {
+	return refreshFamilyKeyPrefix + sessionID
+}
@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	revokedKeyPrefix      = "jwt:revoked:"
+	userSessionsKeyPrefix = "jwt:user_sessions:"
+)
+
+// TokenDenylist implements jwt.Denylist on top of Redis
+// Revoked token and session IDs are stored as keys expiring with the token,
+// and each user's sessions are kept in a set so they can be revoked together
+type TokenDenylist struct {
+	client *redis.Client
+}
+
+// NewTokenDenylist creates a new Redis backed token denylist
+func NewTokenDenylist(client *redis.Client) *TokenDenylist {
+	return &TokenDenylist{client: client}
+}
+
+// Revoke denies the given token or session ID until ttl elapses
+func (d *TokenDenylist) Revoke(ctx context.Context, id string, ttl time.Duration) error {
+	if id == "" || ttl <= 0 {
+		return nil
+	}
+	return d.client.Set(ctx, revokedKey(id), 1, ttl).Err()
+}
+
+// RevokeOnce revokes the ID like Revoke and reports false when it was already revoked
+func (d *TokenDenylist) RevokeOnce(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	if id == "" || ttl <= 0 {
+		return false, nil
+	}
+	return d.client.SetNX(ctx, revokedKey(id), 1, ttl).Result()
+}
+
+// IsRevoked reports whether any of the given IDs has been revoked
+func (d *TokenDenylist) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			keys = append(keys, revokedKey(id))
+		}
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+
+	count, err := d.client.Exists(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// TrackSession adds the session to the user's session set
+func (d *TokenDenylist) TrackSession(ctx context.Context, userID int64, sessionID string, ttl time.Duration) error {
+	key := userSessionsKey(userID)
+
+	pipe := d.client.TxPipeline()
+	pipe.SAdd(ctx, key, sessionID)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeUser revokes every tracked session of the user, except the given ones, and
+// removes them from the set. Sessions created concurrently are left in the set untouched
+func (d *TokenDenylist) RevokeUser(ctx context.Context, userID int64, ttl time.Duration, exceptSessionIDs ...string) error {
+	key := userSessionsKey(userID)
+
+	members, err := d.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	sessionIDs := make([]string, 0, len(members))
+	for _, sessionID := range members {
+		if !slices.Contains(exceptSessionIDs, sessionID) {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	pipe := d.client.TxPipeline()
+	for _, sessionID := range sessionIDs {
+		pipe.Set(ctx, revokedKey(sessionID), 1, ttl)
+	}
+	pipe.SRem(ctx, key, toInterfaces(sessionIDs)...)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func revokedKey(id string) string {
+	return revokedKeyPrefix + id
+}
+
+func userSessionsKey(userID int64) string {
+	return fmt.Sprintf("%s%d", userSessionsKeyPrefix, userID)
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/ozaanmetin/go-microservice-starter/internal/domain/user"
+	"github.com/ozaanmetin/go-microservice-starter/internal/infrastructure/database"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+)
+
+const userCacheKeyPrefix = "cache:user:"
+
+// userNotFoundMarker is cached for IDs that do not belong to a user
+const userNotFoundMarker = "-"
+
+// cachedUser is the cached form of a user, unlike user.User it keeps the password hash
+// since callers such as password re-verification read it from GetByID
+type cachedUser struct {
+	*user.User
+	PasswordHash string `json:"password_hash"`
+}
+
+// CachedUserRepository decorates a user.Repository with a read-through Redis cache for GetByID
+// Unknown IDs are cached for a shorter time, concurrent misses for the same ID share a
+// single database query, and writes invalidate the cached user once they have committed.
+// Reads in a transaction or marked with database.WithPrimary bypass the cache, and the
+// cache fails open so an unavailable Redis only costs the database queries it saves
+type CachedUserRepository struct {
+	user.Repository
+	client      *redis.Client
+	ttl         time.Duration
+	negativeTTL time.Duration
+	group       singleflight.Group
+}
+
+// NewCachedUserRepository creates a new Redis cache in front of repo
+func NewCachedUserRepository(repo user.Repository, client *redis.Client, ttl, negativeTTL time.Duration) *CachedUserRepository {
+	return &CachedUserRepository{
+		Repository:  repo,
+		client:      client,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// GetByID returns the cached user, loading it from the repository on a miss
+func (r *CachedUserRepository) GetByID(ctx context.Context, id int64) (*user.User, error) {
+	if database.RequiresPrimary(ctx) {
+		return r.Repository.GetByID(ctx, id)
+	}
+
+	u, found, err := r.get(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).WithError(err).WithField("user_id", id).Warn("Failed to read user from cache")
+	} else if found {
+		if u == nil {
+			return nil, user.ErrUserNotFound
+		}
+		return u, nil
+	}
+
+	result, err, _ := r.group.Do(strconv.FormatInt(id, 10), func() (any, error) {
+		// The query is shared, so one caller giving up must not fail the others waiting for it.
+		// Fill from the primary, a lagging replica could cache a row that was just invalidated
+		ctx := database.WithPrimary(context.WithoutCancel(ctx))
+
+		u, err := r.Repository.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+			return nil, err
+		}
+
+		if cacheErr := r.set(ctx, id, u); cacheErr != nil {
+			logging.FromContext(ctx).WithError(cacheErr).WithField("user_id", id).Warn("Failed to write user to cache")
+		}
+
+		return u, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers may modify the user, so each gets its own copy of the shared result
+	shared := *result.(*user.User)
+	return &shared, nil
+}
+
+// Create inserts the user and forgets a cached miss for its new ID
+func (r *CachedUserRepository) Create(ctx context.Context, u *user.User) error {
+	if err := r.Repository.Create(ctx, u); err != nil {
+		return err
+	}
+	r.invalidate(ctx, u.ID)
+	return nil
+}
+
+// Update updates the user and invalidates its cached copy
+func (r *CachedUserRepository) Update(ctx context.Context, u *user.User) error {
+	if err := r.Repository.Update(ctx, u); err != nil {
+		return err
+	}
+	r.invalidate(ctx, u.ID)
+	return nil
+}
+
+// Delete soft deletes the user and invalidates its cached copy
+func (r *CachedUserRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// Erase anonymises the user and invalidates its cached copy
+func (r *CachedUserRepository) Erase(ctx context.Context, id int64) error {
+	if err := r.Repository.Erase(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// get returns the cached user, found is false on a cache miss and
+// true with a nil user when the ID is cached as not found
+func (r *CachedUserRepository) get(ctx context.Context, id int64) (*user.User, bool, error) {
+	data, err := r.client.Get(ctx, userCacheKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if string(data) == userNotFoundMarker {
+		return nil, true, nil
+	}
+
+	cached := cachedUser{User: &user.User{}}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached user: %w", err)
+	}
+	cached.User.PasswordHash = cached.PasswordHash
+
+	return cached.User, true, nil
+}
+
+// set caches the user, or a miss when u is nil
+func (r *CachedUserRepository) set(ctx context.Context, id int64, u *user.User) error {
+	if u == nil {
+		if r.negativeTTL <= 0 {
+			return nil
+		}
+		return r.client.Set(ctx, userCacheKey(id), userNotFoundMarker, r.negativeTTL).Err()
+	}
+
+	data, err := json.Marshal(cachedUser{User: u, PasswordHash: u.PasswordHash})
+	if err != nil {
+		return fmt.Errorf("failed to encode user: %w", err)
+	}
+
+	return r.client.Set(ctx, userCacheKey(id), data, r.ttl).Err()
+}
+
+// invalidate removes the cached user once the current transaction, if any, has committed
+// so concurrent readers cannot cache the old row again in between
+func (r *CachedUserRepository) invalidate(ctx context.Context, id int64) {
+	database.AfterCommit(ctx, func() {
+		// The request may be cancelled by the time the transaction commits
+		ctx := context.WithoutCancel(ctx)
+		if err := r.client.Del(ctx, userCacheKey(id)).Err(); err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("user_id", id).Error("Failed to invalidate cached user")
+		}
+	})
+}
+
+func userCacheKey(id int64) string {
+	return fmt.Sprintf("%s%d", userCacheKeyPrefix, id)
+}
@@ -6,8 +6,9 @@ import "fmt"
 
 type ServiceError struct {
 	// Internal fields
-	StatusCode int   `json:"-"`
-	Err        error `json:"-"`
+	StatusCode int               `json:"-"`
+	Err        error             `json:"-"`
+	Headers    map[string]string `json:"-"`
 	// Client-facing fields
 	Code    string                 `json:"code"`
 	Message string                 `json:"message"`
@@ -35,6 +36,15 @@ func (s *ServiceError) AddDetail(key string, value interface{}) *ServiceError {
 	return s
 }
 
+// WithHeader sets a response header sent along with the error
+func (s *ServiceError) WithHeader(key, value string) *ServiceError {
+	if s.Headers == nil {
+		s.Headers = make(map[string]string)
+	}
+	s.Headers[key] = value
+	return s
+}
+
 // Basic ServiceError implementations
 func NewServiceError(statusCode int, code string, message string, err error) *ServiceError {
 	return &ServiceError{
@@ -69,6 +79,10 @@ func NewConflictError(message string, err error) *ServiceError {
 	return NewServiceError(409, "conflict", message, err)
 }
 
+func NewValidationError(message string, err error) *ServiceError {
+	return NewServiceError(422, "validation_error", message, err)
+}
+
 func NewTooManyRequestsError(message string, err error) *ServiceError {
 	return NewServiceError(429, "too_many_requests", message, err)
 }
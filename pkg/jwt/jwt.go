@@ -1,91 +1,331 @@
 package jwt
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var (
 	ErrInvalidToken     = errors.New("invalid token")
 	ErrExpiredToken     = errors.New("token has expired")
 	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrRevokedToken     = errors.New("token has been revoked")
+	ErrNoDenylist       = errors.New("token revocation is not configured")
+	ErrTokenReused      = errors.New("refresh token has already been used")
 )
 
 // TokenType represents the type of JWT token
 type TokenType string
 
 const (
-	AccessToken  TokenType = "access"
-	RefreshToken TokenType = "refresh"
+	AccessToken     TokenType = "access"
+	RefreshToken    TokenType = "refresh"
+	MFAPendingToken TokenType = "mfa_pending"
+	// APIKeyToken marks claims resolved from an API key, they are never signed
+	APIKeyToken TokenType = "api_key"
 )
 
+// defaultMFATokenDuration is how long a user has to complete the second login step
+const defaultMFATokenDuration = 5 * time.Minute
+
 // Claims represents the JWT claims structure
+// The token ID is carried in RegisteredClaims.ID (jti), SessionID is shared by
+// the access and refresh token issued together so both can be revoked at once
 type Claims struct {
-	UserID    int64     `json:"user_id"`
-	Email     string    `json:"email"`
-	TokenType TokenType `json:"token_type"`
+	UserID      int64     `json:"user_id"`
+	Email       string    `json:"email"`
+	Roles       []string  `json:"roles,omitempty"`
+	Permissions []string  `json:"permissions,omitempty"`
+	TokenType   TokenType `json:"token_type"`
+	SessionID   string    `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// HasRole reports whether the claims carry the given role
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasPermission reports whether the claims carry the given permission
+func (c *Claims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+// Subject describes the user a token pair is issued to
+// Roles and Permissions are only embedded in access tokens
+type Subject struct {
+	UserID      int64
+	Email       string
+	Roles       []string
+	Permissions []string
+}
+
 // TokenPair holds both access and refresh tokens
+// SessionID identifies the session both tokens belong to and is not sent to clients
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	SessionID    string `json:"-"`
+}
+
+// Denylist keeps track of revoked tokens and sessions
+type Denylist interface {
+	// Revoke denies the given token or session ID until ttl elapses
+	Revoke(ctx context.Context, id string, ttl time.Duration) error
+	// RevokeOnce revokes the ID like Revoke and reports false when it was already revoked
+	RevokeOnce(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	// IsRevoked reports whether any of the given token or session IDs has been revoked
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
+	// TrackSession associates a session with its user so RevokeUser can find it
+	TrackSession(ctx context.Context, userID int64, sessionID string, ttl time.Duration) error
+	// RevokeUser revokes every tracked session of the user for ttl, except the given sessions
+	RevokeUser(ctx context.Context, userID int64, ttl time.Duration, exceptSessionIDs ...string) error
+}
+
+// RefreshTokenStore records the latest refresh token of every session.
+// All refresh tokens issued for one session form a family, and only the
+// latest member of the family may be exchanged for a new token pair.
+type RefreshTokenStore interface {
+	// Save stores tokenID as the latest refresh token of the session
+	Save(ctx context.Context, sessionID, tokenID string, ttl time.Duration) error
+	// Rotate atomically replaces currentID with nextID and reports false
+	// when currentID is not the latest refresh token of the session
+	Rotate(ctx context.Context, sessionID, currentID, nextID string, ttl time.Duration) (bool, error)
+	// Delete forgets the session so none of its refresh tokens can be rotated
+	Delete(ctx context.Context, sessionID string) error
 }
 
 // Manager handles JWT token operations
 type Manager struct {
-	secretKey            []byte
+	keyRing              atomic.Pointer[KeyRing]
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
+	mfaTokenDuration     time.Duration
+	denylist             Denylist
+	refreshStore         RefreshTokenStore
+}
+
+// Option configures optional Manager dependencies
+type Option func(*Manager)
+
+// WithDenylist enables token revocation checks backed by the given denylist
+func WithDenylist(denylist Denylist) Option {
+	return func(m *Manager) {
+		m.denylist = denylist
+	}
+}
+
+// WithRefreshTokenStore enables single-use refresh tokens with reuse detection
+func WithRefreshTokenStore(store RefreshTokenStore) Option {
+	return func(m *Manager) {
+		m.refreshStore = store
+	}
+}
+
+// WithMFATokenDuration sets how long MFA pending tokens stay valid
+func WithMFATokenDuration(duration time.Duration) Option {
+	return func(m *Manager) {
+		if duration > 0 {
+			m.mfaTokenDuration = duration
+		}
+	}
 }
 
-// NewManager creates a new JWT manager
-func NewManager(secretKey string, accessTokenDuration, refreshTokenDuration time.Duration) *Manager {
-	return &Manager{
-		secretKey:            []byte(secretKey),
+// NewManager creates a new JWT manager signing tokens with the current key of
+// the key ring and verifying them with any key of the ring
+func NewManager(keyRing *KeyRing, accessTokenDuration, refreshTokenDuration time.Duration, opts ...Option) *Manager {
+	m := &Manager{
 		accessTokenDuration:  accessTokenDuration,
 		refreshTokenDuration: refreshTokenDuration,
+		mfaTokenDuration:     defaultMFATokenDuration,
+	}
+	m.keyRing.Store(keyRing)
+
+	for _, opt := range opts {
+		opt(m)
 	}
+
+	return m
+}
+
+// KeyRing returns the keys currently in use
+func (m *Manager) KeyRing() *KeyRing {
+	return m.keyRing.Load()
+}
+
+// RefreshTokenDuration returns how long refresh tokens, and so sessions, stay valid
+func (m *Manager) RefreshTokenDuration() time.Duration {
+	return m.refreshTokenDuration
+}
+
+// SetKeyRing atomically replaces the keys, e.g. after rotating the signing key
+// Tokens signed by keys that are no longer part of the ring stop validating
+func (m *Manager) SetKeyRing(keyRing *KeyRing) {
+	m.keyRing.Store(keyRing)
 }
 
 // GenerateTokenPair creates both access and refresh tokens for a user
-func (m *Manager) GenerateTokenPair(userID int64, email string) (*TokenPair, error) {
-	accessToken, err := m.GenerateToken(userID, email, AccessToken, m.accessTokenDuration)
+// Both tokens share a new session ID which also identifies the refresh token family
+func (m *Manager) GenerateTokenPair(ctx context.Context, subject Subject) (*TokenPair, error) {
+	sessionID := uuid.NewString()
+
+	pair, refreshClaims, err := m.issueTokenPair(subject, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.trackSession(ctx, subject.UserID, sessionID); err != nil {
+		return nil, err
+	}
+
+	if m.refreshStore != nil {
+		if err := m.refreshStore.Save(ctx, sessionID, refreshClaims.ID, m.refreshTokenDuration); err != nil {
+			return nil, fmt.Errorf("failed to save refresh token: %w", err)
+		}
+	}
+
+	return pair, nil
+}
+
+// GenerateMFAPendingToken creates a short-lived token proving the user passed the
+// first login step. It carries no roles or permissions and can only be exchanged
+// for a token pair once the second factor has been verified
+func (m *Manager) GenerateMFAPendingToken(subject Subject) (string, error) {
+	return m.GenerateToken(subject, "", MFAPendingToken, m.mfaTokenDuration)
+}
+
+// RotateTokenPair exchanges a validated refresh token for a new token pair in the
+// same session. The presented refresh token is invalidated; presenting a refresh
+// token that has already been rotated revokes the whole session and returns ErrTokenReused.
+// subject carries the user's current details since they may have changed since the last refresh.
+func (m *Manager) RotateTokenPair(ctx context.Context, claims *Claims, subject Subject) (*TokenPair, error) {
+	if claims.TokenType != RefreshToken || claims.SessionID == "" || claims.UserID != subject.UserID {
+		return nil, ErrInvalidToken
+	}
+
+	pair, refreshClaims, err := m.issueTokenPair(subject, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.refreshStore != nil {
+		rotated, err := m.refreshStore.Rotate(ctx, claims.SessionID, claims.ID, refreshClaims.ID, m.refreshTokenDuration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+
+		if !rotated {
+			if err := m.revokeFamily(ctx, claims.SessionID); err != nil {
+				return nil, err
+			}
+			return nil, ErrTokenReused
+		}
+	}
+
+	// The rotated refresh token outlives the tracking set written at login,
+	// so track the session again or RevokeUser would no longer find it
+	if err := m.trackSession(ctx, subject.UserID, claims.SessionID); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// trackSession records the session in the user's session set and extends its TTL
+// to the lifetime of the newest refresh token
+func (m *Manager) trackSession(ctx context.Context, userID int64, sessionID string) error {
+	if m.denylist == nil {
+		return nil
+	}
+
+	if err := m.denylist.TrackSession(ctx, userID, sessionID, m.refreshTokenDuration); err != nil {
+		return fmt.Errorf("failed to track session: %w", err)
+	}
+
+	return nil
+}
+
+// revokeFamily invalidates every token issued for the session
+func (m *Manager) revokeFamily(ctx context.Context, sessionID string) error {
+	if err := m.refreshStore.Delete(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to delete refresh token family: %w", err)
+	}
+
+	if m.denylist != nil {
+		if err := m.denylist.Revoke(ctx, sessionID, m.refreshTokenDuration); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// issueTokenPair signs an access and refresh token for the given session
+func (m *Manager) issueTokenPair(subject Subject, sessionID string) (*TokenPair, *Claims, error) {
+	accessClaims := m.newClaims(subject, sessionID, AccessToken, m.accessTokenDuration)
+	accessToken, err := m.signClaims(accessClaims)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := m.GenerateToken(userID, email, RefreshToken, m.refreshTokenDuration)
+	refreshClaims := m.newClaims(subject, sessionID, RefreshToken, m.refreshTokenDuration)
+	refreshToken, err := m.signClaims(refreshClaims)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-	}, nil
+		SessionID:    sessionID,
+	}, refreshClaims, nil
 }
 
-// GenerateToken creates a new JWT token
-func (m *Manager) GenerateToken(userID int64, email string, tokenType TokenType, duration time.Duration) (string, error) {
+// GenerateToken creates a new JWT token with a unique token ID
+func (m *Manager) GenerateToken(subject Subject, sessionID string, tokenType TokenType, duration time.Duration) (string, error) {
+	return m.signClaims(m.newClaims(subject, sessionID, tokenType, duration))
+}
+
+func (m *Manager) newClaims(subject Subject, sessionID string, tokenType TokenType, duration time.Duration) *Claims {
 	now := time.Now()
 	claims := &Claims{
-		UserID:    userID,
-		Email:     email,
+		UserID:    subject.UserID,
+		Email:     subject.Email,
 		TokenType: tokenType,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(m.secretKey)
+	if tokenType == AccessToken {
+		claims.Roles = subject.Roles
+		claims.Permissions = subject.Permissions
+	}
+
+	return claims
+}
+
+// signClaims signs the claims with the signing key and sets its kid header
+func (m *Manager) signClaims(claims *Claims) (string, error) {
+	key := m.KeyRing().Current()
+	if !key.CanSign() {
+		return "", fmt.Errorf("key %s has no private key material", key.ID())
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID()
+	tokenString, err := token.SignedString(key.signKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -95,13 +335,7 @@ func (m *Manager) GenerateToken(userID int64, email string, tokenType TokenType,
 
 // ValidateToken validates and parses a JWT token
 func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return m.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.keyFunc)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -121,30 +355,213 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-// ValidateAccessToken validates that the token is an access token
-func (m *Manager) ValidateAccessToken(tokenString string) (*Claims, error) {
-	claims, err := m.ValidateToken(tokenString)
+// keyFunc selects the verification key by the kid header and rejects tokens
+// whose alg header does not match the algorithm of that key
+func (m *Manager) keyFunc(token *jwt.Token) (interface{}, error) {
+	ring := m.KeyRing()
+
+	// Tokens issued before kid headers were introduced fall back to the signing key
+	key := ring.Current()
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = ring.Key(kid); !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.Algorithm() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys that verify tokens issued by this manager,
+// including retiring keys. Symmetric keys are never published
+func (m *Manager) JWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+
+	for _, key := range m.KeyRing().Keys() {
+		if jwk, err := key.JWK(); err == nil {
+			jwks.Keys = append(jwks.Keys, *jwk)
+		}
+	}
+
+	return jwks
+}
+
+// ValidateAccessToken validates that the token is a non-revoked access token
+func (m *Manager) ValidateAccessToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return m.validateTokenOfType(ctx, tokenString, AccessToken)
+}
+
+// ValidateRefreshToken validates that the token is a non-revoked refresh token
+func (m *Manager) ValidateRefreshToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return m.validateTokenOfType(ctx, tokenString, RefreshToken)
+}
+
+// ValidateMFAPendingToken validates that the token is a non-revoked MFA pending token
+func (m *Manager) ValidateMFAPendingToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return m.validateTokenOfType(ctx, tokenString, MFAPendingToken)
+}
+
+// ClaimMFAPendingToken validates an MFA pending token and revokes it in the same step,
+// so concurrent attempts to complete one login cannot both redeem the token
+func (m *Manager) ClaimMFAPendingToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := m.ValidateMFAPendingToken(ctx, tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims.TokenType != AccessToken {
+	if m.denylist == nil {
+		return nil, ErrNoDenylist
+	}
+	if claims.ExpiresAt == nil {
 		return nil, ErrInvalidToken
 	}
 
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil, ErrExpiredToken
+	}
+
+	claimed, err := m.denylist.RevokeOnce(ctx, claims.ID, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke token: %w", err)
+	}
+	if !claimed {
+		return nil, ErrRevokedToken
+	}
+
 	return claims, nil
 }
 
-// ValidateRefreshToken validates that the token is a refresh token
-func (m *Manager) ValidateRefreshToken(tokenString string) (*Claims, error) {
+func (m *Manager) validateTokenOfType(ctx context.Context, tokenString string, tokenType TokenType) (*Claims, error) {
 	claims, err := m.ValidateToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims.TokenType != RefreshToken {
+	if claims.TokenType != tokenType {
 		return nil, ErrInvalidToken
 	}
 
+	if err := m.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
+
+// checkRevoked consults the denylist for the token and its session
+func (m *Manager) checkRevoked(ctx context.Context, claims *Claims) error {
+	if m.denylist == nil {
+		return nil
+	}
+
+	ids := []string{claims.ID}
+	if claims.SessionID != "" {
+		ids = append(ids, claims.SessionID)
+	}
+
+	revoked, err := m.denylist.IsRevoked(ctx, ids...)
+	if err != nil {
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return ErrRevokedToken
+	}
+
+	return nil
+}
+
+// RevokeToken revokes the single token described by claims until it expires
+func (m *Manager) RevokeToken(ctx context.Context, claims *Claims) error {
+	if m.denylist == nil {
+		return ErrNoDenylist
+	}
+
+	if claims.ExpiresAt != nil {
+		if ttl := time.Until(claims.ExpiresAt.Time); ttl > 0 {
+			if err := m.denylist.Revoke(ctx, claims.ID, ttl); err != nil {
+				return fmt.Errorf("failed to revoke token: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RevokeSession revokes the token described by claims together with every
+// other token issued for the same session
+func (m *Manager) RevokeSession(ctx context.Context, claims *Claims) error {
+	if err := m.RevokeToken(ctx, claims); err != nil {
+		return err
+	}
+
+	if claims.SessionID != "" {
+		// A session lives as long as its refresh token
+		if err := m.denylist.Revoke(ctx, claims.SessionID, m.refreshTokenDuration); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RevokeSessionByID revokes a session without a token of it at hand,
+// e.g. when a user signs out another device
+func (m *Manager) RevokeSessionByID(ctx context.Context, sessionID string) error {
+	if m.denylist == nil {
+		return ErrNoDenylist
+	}
+
+	if m.refreshStore != nil {
+		return m.revokeFamily(ctx, sessionID)
+	}
+
+	if err := m.denylist.Revoke(ctx, sessionID, m.refreshTokenDuration); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// IsSessionRevoked reports whether the session has been revoked, always false without a denylist
+func (m *Manager) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if m.denylist == nil {
+		return false, nil
+	}
+
+	revoked, err := m.denylist.IsRevoked(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// RevokeUser revokes every session issued to the user
+func (m *Manager) RevokeUser(ctx context.Context, userID int64) error {
+	if m.denylist == nil {
+		return ErrNoDenylist
+	}
+
+	if err := m.denylist.RevokeUser(ctx, userID, m.refreshTokenDuration); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of the user except the one claims belong to
+func (m *Manager) RevokeOtherSessions(ctx context.Context, claims *Claims) error {
+	if m.denylist == nil {
+		return ErrNoDenylist
+	}
+
+	if err := m.denylist.RevokeUser(ctx, claims.UserID, m.refreshTokenDuration, claims.SessionID); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return nil
+}
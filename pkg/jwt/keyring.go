@@ -0,0 +1,134 @@
+package jwt
+
+import (
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// KeyRing holds the current signing key plus any number of retiring keys
+// that are still accepted for verification, all selected by their kid
+type KeyRing struct {
+	current *SigningKey
+	keys    map[string]*SigningKey
+}
+
+// NewKeyRing creates a key ring signing with current and verifying with
+// current and every verification key. A verification key may repeat the
+// current key, e.g. when both are loaded from a key directory, but a
+// different key under the same kid is an error since it could never be used
+func NewKeyRing(current *SigningKey, verificationKeys ...*SigningKey) (*KeyRing, error) {
+	if current == nil {
+		return nil, fmt.Errorf("%w: no signing key", ErrMissingKeyMaterial)
+	}
+	if !current.CanSign() {
+		return nil, fmt.Errorf("signing key %s has no private key material", current.ID())
+	}
+
+	ring := &KeyRing{
+		current: current,
+		keys:    map[string]*SigningKey{current.ID(): current},
+	}
+
+	for _, key := range verificationKeys {
+		if key.ID() == current.ID() {
+			if !key.sameMaterial(current) {
+				return nil, fmt.Errorf("verification key %s has the key id of the signing key but different key material", key.ID())
+			}
+			continue
+		}
+		if _, exists := ring.keys[key.ID()]; exists {
+			return nil, fmt.Errorf("duplicate key id: %s", key.ID())
+		}
+		ring.keys[key.ID()] = key
+	}
+
+	return ring, nil
+}
+
+// Current returns the key used to sign new tokens
+func (r *KeyRing) Current() *SigningKey {
+	return r.current
+}
+
+// Key returns the verification key with the given kid
+func (r *KeyRing) Key(kid string) (*SigningKey, bool) {
+	key, ok := r.keys[kid]
+	return key, ok
+}
+
+// Keys returns every key of the ring, the current key first
+func (r *KeyRing) Keys() []*SigningKey {
+	keys := make([]*SigningKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		if key != r.current {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID() < keys[j].ID()
+	})
+	return append([]*SigningKey{r.current}, keys...)
+}
+
+// LoadKeyDir loads every key stored in dir. File names encode the key ID and
+// algorithm, in any case, as "<kid>.<alg>.<ext>":
+//   - "<kid>.<alg>.pem" holds a PEM private key, or a public key for verify-only keys
+//   - "<kid>.<alg>.secret" holds an HMAC secret
+//
+// Other files are ignored.
+func LoadKeyDir(dir string) ([]*SigningKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	var keys []*SigningKey
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if ext != ".pem" && ext != ".secret" {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ext)
+		idx := strings.LastIndex(base, ".")
+		if idx <= 0 {
+			return nil, fmt.Errorf("key file %s must be named <kid>.<alg>%s", name, ext)
+		}
+		kid, algorithm := base[:idx], base[idx+1:]
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file %s: %w", name, err)
+		}
+
+		var key *SigningKey
+		if ext == ".secret" {
+			key, err = NewHMACKey(kid, algorithm, strings.TrimSpace(string(data)))
+		} else if isPrivatePEM(data) {
+			key, err = NewKeyFromPEM(kid, algorithm, data, nil)
+		} else {
+			key, err = NewKeyFromPEM(kid, algorithm, nil, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key file %s: %w", name, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func isPrivatePEM(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil && strings.Contains(block.Type, "PRIVATE KEY")
+}
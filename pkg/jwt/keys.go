@@ -0,0 +1,281 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrMissingKeyMaterial   = errors.New("missing key material")
+)
+
+// SigningKey holds the key material for a single key ID
+// Keys loaded from a public key only can verify tokens but not sign them
+type SigningKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// ID returns the key ID placed in the kid header of signed tokens
+func (k *SigningKey) ID() string {
+	return k.id
+}
+
+// Algorithm returns the JWS algorithm name of the key
+func (k *SigningKey) Algorithm() string {
+	return k.method.Alg()
+}
+
+// CanSign reports whether the key holds private material
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// IsSymmetric reports whether the key is a shared HMAC secret
+func (k *SigningKey) IsSymmetric() bool {
+	_, ok := k.method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+// normalizeAlgorithm accepts algorithm names in any case, e.g. "rs256" from a key file name
+func normalizeAlgorithm(algorithm string) string {
+	if strings.EqualFold(algorithm, jwt.SigningMethodEdDSA.Alg()) {
+		return jwt.SigningMethodEdDSA.Alg()
+	}
+	return strings.ToUpper(algorithm)
+}
+
+// sameMaterial reports whether other verifies with the same algorithm and key as k
+func (k *SigningKey) sameMaterial(other *SigningKey) bool {
+	if k.Algorithm() != other.Algorithm() {
+		return false
+	}
+
+	switch key := k.verifyKey.(type) {
+	case []byte:
+		otherKey, ok := other.verifyKey.([]byte)
+		return ok && hmac.Equal(key, otherKey)
+	case interface{ Equal(crypto.PublicKey) bool }:
+		return key.Equal(other.verifyKey)
+	default:
+		return false
+	}
+}
+
+// NewHMACKey creates a symmetric signing key from a shared secret
+// algorithm defaults to HS256 when empty
+func NewHMACKey(id, algorithm, secret string) (*SigningKey, error) {
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+	algorithm = normalizeAlgorithm(algorithm)
+
+	method, ok := jwt.GetSigningMethod(algorithm).(*jwt.SigningMethodHMAC)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not an HMAC algorithm", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("%w: empty HMAC secret", ErrMissingKeyMaterial)
+	}
+
+	if id == "" {
+		id = "default"
+	}
+
+	return &SigningKey{
+		id:        id,
+		method:    method,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}, nil
+}
+
+// LoadKeyFiles reads PEM encoded key files and creates an asymmetric key
+// Either file may be empty: without a private key the key can only verify,
+// without a public key it is derived from the private key
+func LoadKeyFiles(id, algorithm, privateKeyFile, publicKeyFile string) (*SigningKey, error) {
+	var privatePEM, publicPEM []byte
+	var err error
+
+	if privateKeyFile != "" {
+		if privatePEM, err = os.ReadFile(privateKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read private key file: %w", err)
+		}
+	}
+
+	if publicKeyFile != "" {
+		if publicPEM, err = os.ReadFile(publicKeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read public key file: %w", err)
+		}
+	}
+
+	return NewKeyFromPEM(id, algorithm, privatePEM, publicPEM)
+}
+
+// NewKeyFromPEM creates an asymmetric key for RS*, PS*, ES* or EdDSA algorithms
+// When id is empty the RFC 7638 thumbprint of the public key is used
+func NewKeyFromPEM(id, algorithm string, privatePEM, publicPEM []byte) (*SigningKey, error) {
+	algorithm = normalizeAlgorithm(algorithm)
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if len(privatePEM) == 0 && len(publicPEM) == 0 {
+		return nil, fmt.Errorf("%w: %s requires a private or public key", ErrMissingKeyMaterial, algorithm)
+	}
+
+	var signKey, verifyKey interface{}
+	var err error
+
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		signKey, verifyKey, err = parseRSAKeys(privatePEM, publicPEM)
+	case *jwt.SigningMethodECDSA:
+		signKey, verifyKey, err = parseECDSAKeys(m, privatePEM, publicPEM)
+	case *jwt.SigningMethodEd25519:
+		signKey, verifyKey, err = parseEd25519Keys(privatePEM, publicPEM)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		id:        id,
+		method:    method,
+		signKey:   signKey,
+		verifyKey: verifyKey,
+	}
+
+	if key.id == "" {
+		jwk, err := key.JWK()
+		if err != nil {
+			return nil, err
+		}
+		if key.id, err = jwk.Thumbprint(); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+func parseRSAKeys(privatePEM, publicPEM []byte) (interface{}, interface{}, error) {
+	var private *rsa.PrivateKey
+	var public *rsa.PublicKey
+	var err error
+
+	if len(privatePEM) > 0 {
+		if private, err = jwt.ParseRSAPrivateKeyFromPEM(privatePEM); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+		public = &private.PublicKey
+	}
+
+	if len(publicPEM) > 0 {
+		if public, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse RSA public key: %w", err)
+		}
+	}
+
+	if private != nil && !private.PublicKey.Equal(public) {
+		return nil, nil, errors.New("RSA public key does not match private key")
+	}
+
+	if private == nil {
+		return nil, public, nil
+	}
+	return private, public, nil
+}
+
+func parseECDSAKeys(method *jwt.SigningMethodECDSA, privatePEM, publicPEM []byte) (interface{}, interface{}, error) {
+	var private *ecdsa.PrivateKey
+	var public *ecdsa.PublicKey
+	var err error
+
+	if len(privatePEM) > 0 {
+		if private, err = jwt.ParseECPrivateKeyFromPEM(privatePEM); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+		public = &private.PublicKey
+	}
+
+	if len(publicPEM) > 0 {
+		if public, err = jwt.ParseECPublicKeyFromPEM(publicPEM); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse EC public key: %w", err)
+		}
+	}
+
+	if private != nil && !private.PublicKey.Equal(public) {
+		return nil, nil, errors.New("EC public key does not match private key")
+	}
+
+	// ES256 requires P-256, ES384 requires P-384 and ES512 requires P-521
+	if public.Curve.Params().BitSize != method.CurveBits {
+		return nil, nil, fmt.Errorf("EC key curve %s does not match %s", public.Curve.Params().Name, method.Alg())
+	}
+
+	if private == nil {
+		return nil, public, nil
+	}
+	return private, public, nil
+}
+
+func parseEd25519Keys(privatePEM, publicPEM []byte) (interface{}, interface{}, error) {
+	var private ed25519.PrivateKey
+	var public ed25519.PublicKey
+
+	if len(privatePEM) > 0 {
+		key, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse Ed25519 private key: %w", err)
+		}
+		private = key.(ed25519.PrivateKey)
+		public = private.Public().(ed25519.PublicKey)
+	}
+
+	if len(publicPEM) > 0 {
+		key, err := jwt.ParseEdPublicKeyFromPEM(publicPEM)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse Ed25519 public key: %w", err)
+		}
+		public = key.(ed25519.PublicKey)
+	}
+
+	if private != nil && !private.Public().(ed25519.PublicKey).Equal(public) {
+		return nil, nil, errors.New("Ed25519 public key does not match private key")
+	}
+
+	if private == nil {
+		return nil, public, nil
+	}
+	return private, public, nil
+}
+
+// curveName returns the JWK curve name for an elliptic curve
+func curveName(curve elliptic.Curve) string {
+	switch curve {
+	case elliptic.P256():
+		return "P-256"
+	case elliptic.P384():
+		return "P-384"
+	case elliptic.P521():
+		return "P-521"
+	default:
+		return curve.Params().Name
+	}
+}
@@ -0,0 +1,28 @@
+package logging
+
+import "context"
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being served
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, empty if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// FromContext returns the global logger annotated with the request ID carried by ctx
+// so logs written deep inside a request can be correlated with its access log
+func FromContext(ctx context.Context) *Logger {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return L().WithField("request_id", requestID)
+	}
+	return L()
+}
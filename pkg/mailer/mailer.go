@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message is an email to be delivered
+type Message struct {
+	To       []string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Supported mailer drivers
+const (
+	DriverSMTP   = "smtp"
+	DriverLog    = "log"
+	DriverMemory = "memory"
+)
+
+// Config holds mailer configuration
+type Config struct {
+	Driver string
+	From   string
+	SMTP   SMTPConfig
+}
+
+// New creates a mailer for the configured driver, defaulting to SMTP
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP, "":
+		return NewSMTPMailer(cfg.From, cfg.SMTP), nil
+	case DriverMemory:
+		return NewMemoryMailer(), nil
+	case DriverLog:
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ozaanmetin/go-microservice-starter/pkg/logging"
+)
+
+// LogMailer writes messages to the application log instead of delivering them
+// Useful for local development where no SMTP server is available. Bodies carry
+// live tokens such as password reset links, so they are only logged at debug level
+type LogMailer struct{}
+
+// NewLogMailer creates a new log mailer
+func NewLogMailer() *LogMailer {
+	logging.L().Warn("Emails are written to the application log instead of being delivered, bodies only at debug level; use the smtp mail driver in production")
+	return &LogMailer{}
+}
+
+// Send logs the recipient and subject, and the body at debug level
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	logger := logging.L().
+		WithField("to", msg.To).
+		WithField("subject", msg.Subject)
+
+	logger.Info("Email message (log mailer)")
+	logger.WithField("body", msg.TextBody).Debug("Email message body (log mailer)")
+	return nil
+}
+
+// MemoryMailer keeps sent messages in memory so tests can inspect them
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryMailer creates a new in-memory mailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send records the message
+func (m *MemoryMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of every recorded message
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Reset forgets all recorded messages
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
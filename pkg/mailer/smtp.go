@@ -0,0 +1,180 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSMTPTimeout bounds a delivery when neither the config nor the context sets a limit
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig holds SMTP server configuration
+// Timeout bounds connecting to the server and the whole SMTP conversation
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+// SMTPMailer delivers messages through an SMTP server
+// STARTTLS is used automatically when the server supports it
+type SMTPMailer struct {
+	from string
+	cfg  SMTPConfig
+}
+
+// NewSMTPMailer creates a new SMTP mailer sending from the given address
+func NewSMTPMailer(from string, cfg SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{from: from, cfg: cfg}
+}
+
+// Send delivers the message
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := m.build(msg)
+	if err != nil {
+		return err
+	}
+
+	timeout := m.cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := m.send(ctx, msg.To, body); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// send runs the SMTP conversation of smtp.SendMail on a connection bound to ctx,
+// since smtp.SendMail has no timeouts and would block forever on a hung server
+func (m *SMTPMailer) send(ctx context.Context, to []string, body []byte) error {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	// Every read and write fails once the deadline passes, and cancelling ctx
+	// closes the connection to interrupt a conversation that is in progress
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return err
+		}
+	}
+
+	if m.cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+			if err := client.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// build renders the message as MIME, multipart when both bodies are set
+func (m *SMTPMailer) build(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writeHeader := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+
+	writeHeader("From", m.from)
+	writeHeader("To", strings.Join(msg.To, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+
+	switch {
+	case msg.HTMLBody != "" && msg.TextBody != "":
+		boundary, err := newBoundary()
+		if err != nil {
+			return nil, err
+		}
+		writeHeader("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+		buf.WriteString("\r\n")
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain", msg.TextBody},
+			{"text/html", msg.HTMLBody},
+		} {
+			buf.WriteString("--" + boundary + "\r\n")
+			buf.WriteString("Content-Type: " + part.contentType + "; charset=utf-8\r\n\r\n")
+			buf.WriteString(part.body + "\r\n")
+		}
+		buf.WriteString("--" + boundary + "--\r\n")
+	case msg.HTMLBody != "":
+		writeHeader("Content-Type", "text/html; charset=utf-8")
+		buf.WriteString("\r\n" + msg.HTMLBody + "\r\n")
+	default:
+		writeHeader("Content-Type", "text/plain; charset=utf-8")
+		buf.WriteString("\r\n" + msg.TextBody + "\r\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func newBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate MIME boundary: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// dbQueryDuration tracks database query duration in seconds by query, database, and status
+	dbQueryDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database query duration in seconds",
+			Buckets: []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+		},
+		[]string{"query", "database", "status"},
+	)
+)
+
+// RecordDBQuery records the duration of a database query
+// query names the query, e.g. the repository method running it, never the SQL itself
+func RecordDBQuery(query, database string, success bool, duration time.Duration) {
+	status := "ok"
+	if !success {
+		status = "error"
+	}
+	dbQueryDuration.WithLabelValues(query, database, status).Observe(duration.Seconds())
+}
+
+// RegisterDBStats exports the connection pool statistics of db, i.e. open, in-use and idle
+// connections and how often and how long callers waited for one, labelled with name
+func RegisterDBStats(name string, db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
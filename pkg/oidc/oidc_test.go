@@ -0,0 +1,359 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc/oidctest"
+)
+
+var alice = oidctest.User{
+	Subject:       "alice-123",
+	Email:         "alice@example.com",
+	EmailVerified: true,
+	GivenName:     "Alice",
+	FamilyName:    "Liddell",
+}
+
+// login runs the authorization code flow up to the callback and returns the
+// code with the nonce and PKCE verifier the relying party kept for it
+func login(t *testing.T, provider *oidctest.Provider, client *oidc.Client, user oidctest.User) (code, nonce, verifier string) {
+	t.Helper()
+
+	nonce, _ = oidc.RandomString(16)
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := client.AuthCodeURL(context.Background(), "state", nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err = provider.Authorize(authURL, user)
+	if err != nil {
+		t.Fatalf("Authorize rejected the authorization request: %v", err)
+	}
+
+	return code, nonce, verifier
+}
+
+func TestDiscovery(t *testing.T) {
+	provider := oidctest.NewProvider(t, "client", "secret")
+
+	metadata, err := provider.Client().Metadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Issuer != provider.Config.Issuer || metadata.TokenEndpoint != provider.Config.Issuer+"/token" {
+		t.Errorf("metadata = %+v", metadata)
+	}
+}
+
+func TestDiscoveryRejectsInvalidMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		metadata func(issuer string) oidc.Metadata
+	}{
+		{"error status", http.StatusInternalServerError, func(issuer string) oidc.Metadata { return oidc.Metadata{} }},
+		{"issuer mismatch", http.StatusOK, func(issuer string) oidc.Metadata {
+			return oidc.Metadata{
+				Issuer:                "https://attacker.example.com",
+				AuthorizationEndpoint: issuer + "/authorize",
+				TokenEndpoint:         issuer + "/token",
+				JWKSURI:               issuer + "/jwks",
+			}
+		}},
+		{"trailing slash in issuer", http.StatusOK, func(issuer string) oidc.Metadata {
+			return oidc.Metadata{
+				Issuer:                issuer + "/",
+				AuthorizationEndpoint: issuer + "/authorize",
+				TokenEndpoint:         issuer + "/token",
+				JWKSURI:               issuer + "/jwks",
+			}
+		}},
+		{"missing token endpoint", http.StatusOK, func(issuer string) oidc.Metadata {
+			return oidc.Metadata{
+				Issuer:                issuer,
+				AuthorizationEndpoint: issuer + "/authorize",
+				JWKSURI:               issuer + "/jwks",
+			}
+		}},
+		{"missing jwks uri", http.StatusOK, func(issuer string) oidc.Metadata {
+			return oidc.Metadata{
+				Issuer:                issuer,
+				AuthorizationEndpoint: issuer + "/authorize",
+				TokenEndpoint:         issuer + "/token",
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(tt.metadata(server.URL))
+			}))
+			defer server.Close()
+
+			client := oidc.NewClient(oidc.Config{Issuer: server.URL, ClientID: "client"})
+			if _, err := client.Metadata(context.Background()); !errors.Is(err, oidc.ErrDiscovery) {
+				t.Errorf("Metadata error = %v, want ErrDiscovery", err)
+			}
+		})
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	provider := oidctest.NewProvider(t, "client", "secret")
+	provider.Config.Scopes = []string{"email"}
+
+	authURL, err := provider.Client().AuthCodeURL(context.Background(), "the-state", "the-nonce", oidc.CodeChallenge("verifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client",
+		"redirect_uri":          provider.Config.RedirectURL,
+		"scope":                 "openid email",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        oidc.CodeChallenge("verifier"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := parsed.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636 Appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	if got := oidc.CodeChallenge(verifier); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallenge = %q", got)
+	}
+}
+
+func TestExchangeWithPKCE(t *testing.T) {
+	provider := oidctest.NewProvider(t, "client", "s3cret:/+")
+	client := provider.Client()
+	ctx := context.Background()
+
+	code, nonce, verifier := login(t, provider, client, alice)
+
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+
+	claims, err := client.VerifyIDToken(ctx, token.IDToken, nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken returned error: %v", err)
+	}
+	if claims.Subject != alice.Subject || claims.Email != alice.Email || !bool(claims.EmailVerified) || claims.GivenName != alice.GivenName {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// Codes are single use
+	if _, err := client.Exchange(ctx, code, verifier); !errors.Is(err, oidc.ErrExchange) {
+		t.Errorf("reusing a code: error = %v, want ErrExchange", err)
+	}
+}
+
+func TestExchangeRejectsInvalidGrants(t *testing.T) {
+	provider := oidctest.NewProvider(t, "client", "secret")
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		client func() *oidc.Client
+		mutate func(code, verifier string) (string, string)
+	}{
+		{"wrong verifier", provider.Client, func(code, verifier string) (string, string) {
+			other, _ := oidc.NewCodeVerifier()
+			return code, other
+		}},
+		{"missing verifier", provider.Client, func(code, verifier string) (string, string) { return code, "" }},
+		{"unknown code", provider.Client, func(code, verifier string) (string, string) { return "forged", verifier }},
+		{"wrong client secret", func() *oidc.Client {
+			cfg := provider.Config
+			cfg.ClientSecret = "guessed"
+			return oidc.NewClient(cfg)
+		}, func(code, verifier string) (string, string) { return code, verifier }},
+		{"other redirect uri", func() *oidc.Client {
+			cfg := provider.Config
+			cfg.RedirectURL = "https://attacker.example.com/callback"
+			return oidc.NewClient(cfg)
+		}, func(code, verifier string) (string, string) { return code, verifier }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, verifier := login(t, provider, provider.Client(), alice)
+			code, verifier = tt.mutate(code, verifier)
+
+			if _, err := tt.client().Exchange(ctx, code, verifier); !errors.Is(err, oidc.ErrExchange) {
+				t.Errorf("Exchange error = %v, want ErrExchange", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	provider := oidctest.NewProvider(t, "client", "secret")
+	impostor := oidctest.NewProvider(t, "client", "secret")
+	client := provider.Client()
+
+	withClaims := func(edit func(claims jwt.MapClaims)) string {
+		claims := provider.IDTokenClaims(alice, "nonce")
+		edit(claims)
+		return provider.SignIDToken(claims)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"valid", withClaims(func(jwt.MapClaims) {}), true},
+		{"expired within leeway", withClaims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Second).Unix() }), true},
+		{"multiple audiences with azp", withClaims(func(c jwt.MapClaims) {
+			c["aud"] = []string{"client", "other"}
+			c["azp"] = "client"
+		}), true},
+		{"wrong nonce", withClaims(func(c jwt.MapClaims) { c["nonce"] = "replayed" }), false},
+		{"missing nonce", withClaims(func(c jwt.MapClaims) { delete(c, "nonce") }), false},
+		{"wrong audience", withClaims(func(c jwt.MapClaims) { c["aud"] = "other-client" }), false},
+		{"multiple audiences without azp", withClaims(func(c jwt.MapClaims) { c["aud"] = []string{"client", "other"} }), false},
+		{"multiple audiences with other azp", withClaims(func(c jwt.MapClaims) {
+			c["aud"] = []string{"client", "other"}
+			c["azp"] = "other"
+		}), false},
+		{"wrong issuer", withClaims(func(c jwt.MapClaims) { c["iss"] = impostor.Config.Issuer }), false},
+		{"expired", withClaims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }), false},
+		{"missing expiry", withClaims(func(c jwt.MapClaims) { delete(c, "exp") }), false},
+		{"issued in the future", withClaims(func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() }), false},
+		{"missing subject", withClaims(func(c jwt.MapClaims) { delete(c, "sub") }), false},
+		{"signed by another key", impostor.SignIDToken(provider.IDTokenClaims(alice, "nonce")), false},
+		{"unknown key id", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, provider.IDTokenClaims(alice, "nonce"))
+			token.Header["kid"] = "rotated-away"
+			return provider.Sign(token)
+		}(), false},
+		{"alg none", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, provider.IDTokenClaims(alice, "nonce"))
+			token.Header["kid"] = oidctest.KeyID
+			signed, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return signed
+		}(), false},
+		{"alg HS256 keyed with the client secret", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, provider.IDTokenClaims(alice, "nonce"))
+			token.Header["kid"] = oidctest.KeyID
+			signed, _ := token.SignedString([]byte("secret"))
+			return signed
+		}(), false},
+		{"malformed", "not.a.token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := client.VerifyIDToken(context.Background(), tt.token, "nonce")
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("VerifyIDToken returned error: %v", err)
+				}
+				if claims.Subject != alice.Subject {
+					t.Errorf("subject = %q, want %q", claims.Subject, alice.Subject)
+				}
+				return
+			}
+			if !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("VerifyIDToken error = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestUserInfo(t *testing.T) {
+	provider := oidctest.NewProvider(t, "client", "secret")
+	provider.ProfileInUserInfo = true
+	client := provider.Client()
+	ctx := context.Background()
+
+	code, nonce, verifier := login(t, provider, client, alice)
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idClaims, err := client.VerifyIDToken(ctx, token.IDToken, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idClaims.Email != "" {
+		t.Fatalf("ID token carries email %q, want none", idClaims.Email)
+	}
+
+	claims, err := client.UserInfo(ctx, token.AccessToken, idClaims.Subject)
+	if err != nil {
+		t.Fatalf("UserInfo returned error: %v", err)
+	}
+	if claims.Email != alice.Email || !bool(claims.EmailVerified) {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	if _, err := client.UserInfo(ctx, token.AccessToken, "someone-else"); !errors.Is(err, oidc.ErrSubjectMismatch) {
+		t.Errorf("UserInfo error = %v, want ErrSubjectMismatch", err)
+	}
+	if _, err := client.UserInfo(ctx, "revoked", idClaims.Subject); !errors.Is(err, oidc.ErrUserInfo) {
+		t.Errorf("UserInfo error = %v, want ErrUserInfo", err)
+	}
+}
+
+func TestBoolUnmarshal(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    bool
+		invalid bool
+	}{
+		{`true`, true, false},
+		{`false`, false, false},
+		{`"true"`, true, false},
+		{`"false"`, false, false},
+		{`null`, false, false},
+		{`"yes please"`, false, true},
+		{`1`, false, true},
+	}
+
+	for _, tt := range tests {
+		var b oidc.Bool
+		err := json.Unmarshal([]byte(tt.json), &b)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) accepted an invalid value", tt.json)
+			}
+			continue
+		}
+		if err != nil || bool(b) != tt.want {
+			t.Errorf("Unmarshal(%s) = (%v, %v), want (%v, nil)", tt.json, b, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// Package oidctest provides a fake OpenID provider for testing relying parties
+// against the authorization code flow with PKCE without a real identity provider
+package oidctest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	pkgJWT "github.com/ozaanmetin/go-microservice-starter/pkg/jwt"
+	"github.com/ozaanmetin/go-microservice-starter/pkg/oidc"
+)
+
+// KeyID is the kid of the provider's signing key
+const KeyID = "oidctest-key"
+
+// User is the account a user signs in with at the provider
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// Provider is a fake OpenID provider serving discovery, JWKS, token and
+// userinfo endpoints. Authorization codes are issued by Authorize, which
+// stands in for the user signing in at the authorization endpoint
+type Provider struct {
+	Server *httptest.Server
+	Config oidc.Config
+
+	// ProfileInUserInfo leaves the profile claims out of ID tokens so
+	// relying parties have to fetch them from the userinfo endpoint
+	ProfileInUserInfo bool
+
+	signer ed25519.PrivateKey
+	jwks   pkgJWT.JWKS
+
+	mu           sync.Mutex
+	codes        map[string]*grant
+	accessTokens map[string]User
+}
+
+// grant is an authorization code waiting to be exchanged
+type grant struct {
+	user          User
+	nonce         string
+	codeChallenge string
+	redirectURL   string
+}
+
+// NewProvider starts a fake provider for a relying party registered with the
+// given client ID and secret. It is shut down when the test ends
+func NewProvider(t testing.TB, clientID, clientSecret string) *Provider {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := pkgJWT.NewKeyFromPEM(KeyID, "EdDSA", nil, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := key.JWK()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Provider{
+		signer:       private,
+		jwks:         pkgJWT.JWKS{Keys: []pkgJWT.JWK{*jwk}},
+		codes:        make(map[string]*grant),
+		accessTokens: make(map[string]User),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("GET /jwks", p.handleJWKS)
+	mux.HandleFunc("POST /token", p.handleToken)
+	mux.HandleFunc("GET /userinfo", p.handleUserInfo)
+
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Server.Close)
+
+	p.Config = oidc.Config{
+		Issuer:       p.Server.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  "http://localhost/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+
+	return p
+}
+
+// Client returns a relying party client registered at the provider
+func (p *Provider) Client() *oidc.Client {
+	return oidc.NewClient(p.Config, oidc.WithHTTPClient(p.Server.Client()))
+}
+
+// Authorize signs the user in for the authorization request URL built by
+// oidc.Client.AuthCodeURL and returns the authorization code sent to the callback
+func (p *Provider) Authorize(authURL string, user User) (string, error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+
+	switch {
+	case query.Get("response_type") != "code":
+		return "", errors.New("unsupported response_type")
+	case query.Get("client_id") != p.Config.ClientID:
+		return "", errors.New("unknown client_id")
+	case query.Get("redirect_uri") != p.Config.RedirectURL:
+		return "", errors.New("redirect_uri is not registered")
+	case !strings.Contains(" "+query.Get("scope")+" ", " openid "):
+		return "", errors.New("openid scope missing")
+	case query.Get("state") == "":
+		return "", errors.New("state missing")
+	case query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "":
+		return "", errors.New("S256 code challenge required")
+	}
+
+	code, err := oidc.RandomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.codes[code] = &grant{
+		user:          user,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURL:   query.Get("redirect_uri"),
+	}
+
+	return code, nil
+}
+
+// SignIDToken signs claims with the provider key
+func (p *Provider) SignIDToken(claims jwt.Claims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = KeyID
+	return p.Sign(token)
+}
+
+// Sign signs a token with the provider key as is, so tests can forge
+// headers such as an unknown kid. The token method must be EdDSA
+func (p *Provider) Sign(token *jwt.Token) string {
+	signed, err := token.SignedString(p.signer)
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: failed to sign token: %v", err))
+	}
+	return signed
+}
+
+// IDTokenClaims returns valid ID token claims for the user
+func (p *Provider) IDTokenClaims(user User, nonce string) jwt.MapClaims {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.Config.Issuer,
+		"sub":   user.Subject,
+		"aud":   p.Config.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	if !p.ProfileInUserInfo {
+		for name, value := range profileClaims(user) {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+func profileClaims(user User) map[string]interface{} {
+	claims := map[string]interface{}{
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+	}
+	if user.GivenName != "" {
+		claims["given_name"] = user.GivenName
+	}
+	if user.FamilyName != "" {
+		claims["family_name"] = user.FamilyName
+	}
+	return claims
+}
+
+func (p *Provider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, oidc.Metadata{
+		Issuer:                p.Config.Issuer,
+		AuthorizationEndpoint: p.Config.Issuer + "/authorize",
+		TokenEndpoint:         p.Config.Issuer + "/token",
+		UserInfoEndpoint:      p.Config.Issuer + "/userinfo",
+		JWKSURI:               p.Config.Issuer + "/jwks",
+	})
+}
+
+func (p *Provider) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, p.jwks)
+}
+
+// handleToken exchanges an authorization code, checking the client
+// credentials, the redirect URI and the PKCE verifier (RFC 7636 4.6)
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+	if !ok || clientID != p.Config.ClientID || clientSecret != p.Config.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// Codes are single use, a failed exchange burns the code too
+	p.mu.Lock()
+	code := r.PostFormValue("code")
+	g, found := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !found ||
+		r.PostFormValue("redirect_uri") != g.redirectURL ||
+		oidc.CodeChallenge(r.PostFormValue("code_verifier")) != g.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	accessToken, err := oidc.RandomString(16)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	p.mu.Lock()
+	p.accessTokens[accessToken] = g.user
+	p.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, oidc.Token{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		IDToken:     p.SignIDToken(p.IDTokenClaims(g.user, g.nonce)),
+		ExpiresIn:   300,
+	})
+}
+
+func (p *Provider) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	accessToken, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	p.mu.Lock()
+	user, ok := p.accessTokens[accessToken]
+	p.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	claims := profileClaims(user)
+	claims["sub"] = user.Subject
+	writeJSON(w, http.StatusOK, claims)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,28 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrStateNotFound = errors.New("login state not found or expired")
+)
+
+// State is what the relying party remembers between redirecting the user to
+// the provider and handling the callback
+type State struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// StateStore keeps pending login states keyed by the state parameter
+type StateStore interface {
+	// Save stores the state until ttl elapses
+	Save(ctx context.Context, key string, state *State, ttl time.Duration) error
+	// Take returns and deletes the state, so each state can only be used once,
+	// returning ErrStateNotFound if it does not exist
+	Take(ctx context.Context, key string) (*State, error)
+}
@@ -0,0 +1,124 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// cursor is the position of a row in a sorted list
+// Values holds the sort field values of the row, in the order of the sort
+type cursor struct {
+	Sort     string `json:"s"`
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// encodeCursor returns the opaque token clients send back to continue from a row
+func encodeCursor(c *cursor) string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+
+	// Keep numbers as json.Number so large IDs are passed to the database unchanged
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var c cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+
+	for i, value := range c.Values {
+		switch v := value.(type) {
+		case json.Number:
+			c.Values[i] = v.String()
+		case string, bool:
+		default:
+			return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+		}
+	}
+
+	return &c, nil
+}
+
+// parseCursorValue converts a decoded cursor value to the type of its sort field so a
+// tampered cursor is rejected here rather than by the database
+func parseCursorValue(value any, typ reflect.Type) (any, error) {
+	invalid := fmt.Errorf("%w: value does not match its sort field", ErrInvalidCursor)
+
+	// A Value func returning an untyped nil gives no type to check against
+	if typ == nil {
+		return nil, invalid
+	}
+
+	if typ == reflect.TypeOf(time.Time{}) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, invalid
+		}
+		return t, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		n, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return nil, invalid
+		}
+		return n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		n, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return nil, invalid
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		f, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return nil, invalid
+		}
+		return f, nil
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return nil, invalid
+		}
+		return value, nil
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return nil, invalid
+		}
+		return value, nil
+	}
+
+	return nil, invalid
+}
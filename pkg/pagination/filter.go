@@ -0,0 +1,112 @@
+package pagination
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Operator compares a column with a filter value
+type Operator string
+
+const (
+	OpEq       Operator = "="
+	OpNe       Operator = "<>"
+	OpLt       Operator = "<"
+	OpLte      Operator = "<="
+	OpGt       Operator = ">"
+	OpGte      Operator = ">="
+	OpContains Operator = "contains"
+	OpNull     Operator = "null"
+)
+
+// Filter restricts a list to the rows whose column matches the value
+// Column is trusted SQL, it must come from the repository and never from the request
+type Filter struct {
+	Column string
+	Op     Operator
+	Value  any
+}
+
+// Eq matches rows whose column equals value
+func Eq(column string, value any) Filter {
+	return Filter{Column: column, Op: OpEq, Value: value}
+}
+
+// Ne matches rows whose column differs from value
+func Ne(column string, value any) Filter {
+	return Filter{Column: column, Op: OpNe, Value: value}
+}
+
+// Lt matches rows whose column is smaller than value
+func Lt(column string, value any) Filter {
+	return Filter{Column: column, Op: OpLt, Value: value}
+}
+
+// Lte matches rows whose column is smaller than or equal to value
+func Lte(column string, value any) Filter {
+	return Filter{Column: column, Op: OpLte, Value: value}
+}
+
+// Gt matches rows whose column is greater than value
+func Gt(column string, value any) Filter {
+	return Filter{Column: column, Op: OpGt, Value: value}
+}
+
+// Gte matches rows whose column is greater than or equal to value
+func Gte(column string, value any) Filter {
+	return Filter{Column: column, Op: OpGte, Value: value}
+}
+
+// Contains matches rows whose column contains value, ignoring case
+func Contains(column string, value string) Filter {
+	return Filter{Column: column, Op: OpContains, Value: value}
+}
+
+// Null matches rows whose column is NULL when isNull is true and NOT NULL when it is false
+func Null(column string, isNull *bool) Filter {
+	return Filter{Column: column, Op: OpNull, Value: isNull}
+}
+
+// active reports whether the filter has a value, nil pointers and empty strings mean "not filtered"
+func (f Filter) active() bool {
+	if f.Value == nil {
+		return false
+	}
+
+	v := reflect.ValueOf(f.Value)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	return v.Kind() != reflect.String || v.Len() > 0
+}
+
+// sql returns the condition of the filter with ? placeholders
+func (f Filter) sql() (string, []any) {
+	v := reflect.ValueOf(f.Value)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	switch f.Op {
+	case OpContains:
+		return f.Column + ` ILIKE ? ESCAPE '\'`, []any{"%" + escapeLike(v.String()) + "%"}
+	case OpNull:
+		if v.Bool() {
+			return f.Column + " IS NULL", nil
+		}
+		return f.Column + " IS NOT NULL", nil
+	default:
+		return f.Column + " " + string(f.Op) + " ?", []any{v.Interface()}
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,67 @@
+package pagination
+
+// Meta is the pagination part of a list response, embed it next to the items
+// Page and TotalPages describe offset pagination, the cursors continue the
+// list from the last item (NextCursor) or before the first one (PrevCursor)
+type Meta struct {
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size"`
+	Total      int64  `json:"total"`
+	TotalPages int64  `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Paginate trims the items fetched with the query to a page and describes it
+// items must be the rows of a query using Where, OrderBy and Limit, total the count for CountWhere
+func (q *Query[T]) Paginate(items []T, total int64) ([]T, Meta) {
+	hasMore := len(items) > q.limit
+	if hasMore {
+		items = items[:q.limit]
+	}
+
+	backward := q.cursor != nil && q.cursor.Backward
+	if backward {
+		// Backward pages are fetched in reverse order
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	meta := Meta{
+		PageSize:   q.limit,
+		Total:      total,
+		TotalPages: (total + int64(q.limit) - 1) / int64(q.limit),
+	}
+	if q.cursor == nil {
+		meta.Page = q.page
+	}
+
+	if len(items) == 0 {
+		return items, meta
+	}
+
+	// Going backwards there is always a next page, the one the cursor came from
+	if hasMore || backward {
+		meta.NextCursor = q.cursorAt(items[len(items)-1], false)
+	}
+	if (backward && hasMore) || (!backward && (q.cursor != nil || q.page > 1)) {
+		meta.PrevCursor = q.cursorAt(items[0], true)
+	}
+
+	return items, meta
+}
+
+// cursorAt returns the cursor continuing the list from item in the given direction
+func (q *Query[T]) cursorAt(item T, backward bool) string {
+	values := make([]any, 0, len(q.orders))
+	for _, order := range q.orders {
+		values = append(values, q.spec.Fields[order.Field].Value(item))
+	}
+
+	return encodeCursor(&cursor{
+		Sort:     q.sortKey(),
+		Values:   values,
+		Backward: backward,
+	})
+}
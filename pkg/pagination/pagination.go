@@ -0,0 +1,289 @@
+// Package pagination provides offset and cursor (keyset) pagination, whitelisted
+// sorting and typed filters for list endpoints backed by SQL queries
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	// MaxPage bounds offset pagination, deeper pages are reached with cursors
+	MaxPage = 1000
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidPage   = errors.New("invalid page")
+)
+
+// Request holds the pagination query parameters of a list endpoint, embed it in request structs
+// Sort is a comma separated list of fields, prefixed with "-" for descending order.
+// A Cursor from a previous response takes precedence over Page
+type Request struct {
+	Page     int    `query:"page" validate:"omitempty,min=1,max=1000"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+	Cursor   string `query:"cursor" validate:"omitempty,max=1024"`
+	Sort     string `query:"sort" validate:"omitempty,max=200"`
+}
+
+// Field is a sortable field of T
+// Column must be NOT NULL for keyset pagination to be exact
+type Field[T any] struct {
+	Column string
+	Value  func(item T) any
+}
+
+// valueType returns the type Value returns, probed with a zero item
+func (f Field[T]) valueType() reflect.Type {
+	var item T
+	if t := reflect.TypeOf(&item).Elem(); t.Kind() == reflect.Pointer {
+		item = reflect.New(t.Elem()).Interface().(T)
+	}
+	return reflect.TypeOf(f.Value(item))
+}
+
+// Spec whitelists the fields a list of T can be sorted by
+// Tiebreaker names a unique field appended to every sort so the order is total
+type Spec[T any] struct {
+	Fields     map[string]Field[T]
+	Default    string
+	Tiebreaker string
+}
+
+// Order is a single sort criterion
+type Order struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Query is a validated page request for a list of T
+type Query[T any] struct {
+	spec    *Spec[T]
+	orders  []Order
+	filters []Filter
+	page    int
+	limit   int
+	cursor  *cursor
+}
+
+// Query validates the request against the spec
+// Unknown sort fields, malformed cursors and pages beyond MaxPage are reported
+// with ErrInvalidSort, ErrInvalidCursor and ErrInvalidPage
+func (s *Spec[T]) Query(req Request) (*Query[T], error) {
+	sort := req.Sort
+	if sort == "" {
+		sort = s.Default
+	}
+
+	orders, err := s.parseSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query[T]{
+		spec:   s,
+		orders: orders,
+		page:   req.Page,
+		limit:  req.PageSize,
+	}
+	if q.page < 1 {
+		q.page = 1
+	}
+	if q.page > MaxPage {
+		return nil, fmt.Errorf("%w: pages beyond %d must be reached with a cursor", ErrInvalidPage, MaxPage)
+	}
+	if q.limit < 1 {
+		q.limit = DefaultPageSize
+	}
+	if q.limit > MaxPageSize {
+		q.limit = MaxPageSize
+	}
+
+	if req.Cursor != "" {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != q.sortKey() || len(c.Values) != len(q.orders) {
+			return nil, fmt.Errorf("%w: cursor belongs to a different sort", ErrInvalidCursor)
+		}
+		for i, order := range q.orders {
+			value, err := parseCursorValue(c.Values[i], s.Fields[order.Field].valueType())
+			if err != nil {
+				return nil, err
+			}
+			c.Values[i] = value
+		}
+		q.cursor = c
+	}
+
+	return q, nil
+}
+
+// parseSort converts a sort expression to orders ending with the tiebreaker
+func (s *Spec[T]) parseSort(sort string) ([]Order, error) {
+	orders := make([]Order, 0)
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(part, "-")
+
+		field, ok := s.Fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not a sortable field", ErrInvalidSort, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %q is listed more than once", ErrInvalidSort, name)
+		}
+		seen[name] = true
+
+		orders = append(orders, Order{Field: name, Column: field.Column, Desc: desc})
+	}
+
+	if !seen[s.Tiebreaker] {
+		// Follow the direction of the last criterion so indexes can be scanned in one direction
+		desc := len(orders) > 0 && orders[len(orders)-1].Desc
+		orders = append(orders, Order{Field: s.Tiebreaker, Column: s.Fields[s.Tiebreaker].Column, Desc: desc})
+	}
+
+	return orders, nil
+}
+
+// sortKey identifies the sort of the query, cursors are only valid for the sort they were created with
+func (q *Query[T]) sortKey() string {
+	parts := make([]string, 0, len(q.orders))
+	for _, order := range q.orders {
+		if order.Desc {
+			parts = append(parts, "-"+order.Field)
+		} else {
+			parts = append(parts, order.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Filter returns a copy of the query with the filters added, filters without a value are skipped
+func (q *Query[T]) Filter(filters ...Filter) *Query[T] {
+	filtered := *q
+	filtered.filters = append([]Filter{}, q.filters...)
+	for _, filter := range filters {
+		if filter.active() {
+			filtered.filters = append(filtered.filters, filter)
+		}
+	}
+	return &filtered
+}
+
+// Orders returns the sort criteria of the query
+func (q *Query[T]) Orders() []Order {
+	return q.orders
+}
+
+// Where returns the WHERE clause combining the base conditions, the filters and
+// the cursor position, with ? placeholders to rebind for the driver
+func (q *Query[T]) Where(base ...string) (string, []any) {
+	conditions, args := q.conditions(base)
+
+	if q.cursor != nil {
+		condition, cursorArgs := q.keyset()
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	return where(conditions), args
+}
+
+// CountWhere returns the WHERE clause of Where without the cursor position,
+// for counting every item matching the filters
+func (q *Query[T]) CountWhere(base ...string) (string, []any) {
+	conditions, args := q.conditions(base)
+	return where(conditions), args
+}
+
+func (q *Query[T]) conditions(base []string) ([]string, []any) {
+	conditions := append([]string{}, base...)
+	args := make([]any, 0, len(q.filters))
+
+	for _, filter := range q.filters {
+		condition, filterArgs := filter.sql()
+		conditions = append(conditions, condition)
+		args = append(args, filterArgs...)
+	}
+
+	return conditions, args
+}
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// keyset returns the condition selecting the rows after the cursor in sort order,
+// or before it when paging backwards
+func (q *Query[T]) keyset() (string, []any) {
+	alternatives := make([]string, 0, len(q.orders))
+	args := make([]any, 0)
+
+	for i, order := range q.orders {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, q.orders[j].Column+" = ?")
+			args = append(args, q.cursor.Values[j])
+		}
+
+		// Rows after the cursor are greater in ascending and smaller in descending order
+		operator := ">"
+		if order.Desc != q.cursor.Backward {
+			operator = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s ?", order.Column, operator))
+		args = append(args, q.cursor.Values[i])
+
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// OrderBy returns the ORDER BY clause, reversed when paging backwards
+func (q *Query[T]) OrderBy() string {
+	parts := make([]string, 0, len(q.orders))
+	for _, order := range q.orders {
+		desc := order.Desc
+		if q.cursor != nil && q.cursor.Backward {
+			desc = !desc
+		}
+
+		if desc {
+			parts = append(parts, order.Column+" DESC")
+		} else {
+			parts = append(parts, order.Column+" ASC")
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// Limit returns the LIMIT and OFFSET clause
+// One row more than the page size is fetched to tell whether another page follows
+func (q *Query[T]) Limit() string {
+	if q.cursor != nil {
+		return fmt.Sprintf(" LIMIT %d", q.limit+1)
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", q.limit+1, (q.page-1)*q.limit)
+}
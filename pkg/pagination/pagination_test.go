@@ -0,0 +1,381 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+var testSpec = Spec[*item]{
+	Fields: map[string]Field[*item]{
+		"id":         {Column: "id", Value: func(i *item) any { return i.ID }},
+		"name":       {Column: "name", Value: func(i *item) any { return i.Name }},
+		"created_at": {Column: "created_at", Value: func(i *item) any { return i.CreatedAt }},
+	},
+	Default:    "id",
+	Tiebreaker: "id",
+}
+
+func mustQuery(t *testing.T, req Request) *Query[*item] {
+	t.Helper()
+	q, err := testSpec.Query(req)
+	if err != nil {
+		t.Fatalf("Query(%+v) returned error: %v", req, err)
+	}
+	return q
+}
+
+func rawCursor(json string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(json))
+}
+
+func TestQuerySort(t *testing.T) {
+	tests := []struct {
+		sort    string
+		orderBy string
+		err     error
+	}{
+		{"", " ORDER BY id ASC", nil},
+		{"-id", " ORDER BY id DESC", nil},
+		{"name", " ORDER BY name ASC, id ASC", nil},
+		{"-name", " ORDER BY name DESC, id DESC", nil},
+		{"created_at,-name", " ORDER BY created_at ASC, name DESC, id DESC", nil},
+		{" name , ", " ORDER BY name ASC, id ASC", nil},
+		{"password_hash", "", ErrInvalidSort},
+		{"name;DROP TABLE users", "", ErrInvalidSort},
+		{"name,-name", "", ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			q, err := testSpec.Query(Request{Sort: tt.sort})
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Query error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.OrderBy(); got != tt.orderBy {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.orderBy)
+			}
+		})
+	}
+}
+
+func TestQueryLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   Request
+		limit string
+	}{
+		{"defaults", Request{}, " LIMIT 21 OFFSET 0"},
+		{"third page", Request{Page: 3, PageSize: 10}, " LIMIT 11 OFFSET 20"},
+		{"page size capped", Request{PageSize: 1000}, " LIMIT 101 OFFSET 0"},
+		{"last allowed page", Request{Page: MaxPage, PageSize: MaxPageSize}, " LIMIT 101 OFFSET 99900"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustQuery(t, tt.req).Limit(); got != tt.limit {
+				t.Errorf("Limit() = %q, want %q", got, tt.limit)
+			}
+		})
+	}
+
+	if _, err := testSpec.Query(Request{Page: MaxPage + 1}); !errors.Is(err, ErrInvalidPage) {
+		t.Errorf("Query beyond MaxPage error = %v, want ErrInvalidPage", err)
+	}
+}
+
+func TestQueryWhereWithFilters(t *testing.T) {
+	active := true
+	deleted := false
+	empty := ""
+	var missing *int64
+
+	q := mustQuery(t, Request{}).Filter(
+		Eq("is_active", &active),
+		Null("deleted_at", &deleted),
+		Contains("email", `50%_off\`),
+		Gte("id", int64(10)),
+		Eq("role", missing),
+		Eq("name", &empty),
+	)
+
+	where, args := q.Where("tenant_id = ?")
+	wantWhere := ` WHERE tenant_id = ? AND is_active = ? AND deleted_at IS NOT NULL AND email ILIKE ? ESCAPE '\' AND id >= ?`
+	if where != wantWhere {
+		t.Errorf("Where() = %q, want %q", where, wantWhere)
+	}
+	wantArgs := []any{true, `%50\%\_off\\%`, int64(10)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("Where() args = %#v, want %#v", args, wantArgs)
+	}
+
+	if where, args := mustQuery(t, Request{}).Where(); where != "" || len(args) != 0 {
+		t.Errorf("Where() without conditions = (%q, %v), want empty", where, args)
+	}
+}
+
+func TestFilterReturnsACopy(t *testing.T) {
+	base := mustQuery(t, Request{})
+	filtered := base.Filter(Gt("id", int64(1)))
+
+	if where, _ := base.Where(); where != "" {
+		t.Errorf("Filter modified the original query: %q", where)
+	}
+	if where, _ := filtered.Where(); where != " WHERE id > ?" {
+		t.Errorf("filtered Where() = %q", where)
+	}
+}
+
+func TestKeysetWhere(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    string
+		cursor  string
+		where   string
+		args    []any
+		orderBy string
+	}{
+		{
+			name:    "forward ascending",
+			sort:    "name",
+			cursor:  `{"s":"name,id","v":["bob",7]}`,
+			where:   " WHERE ((name > ?) OR (name = ? AND id > ?))",
+			args:    []any{"bob", "bob", int64(7)},
+			orderBy: " ORDER BY name ASC, id ASC",
+		},
+		{
+			name:    "forward descending",
+			sort:    "-name",
+			cursor:  `{"s":"-name,-id","v":["bob",7]}`,
+			where:   " WHERE ((name < ?) OR (name = ? AND id < ?))",
+			args:    []any{"bob", "bob", int64(7)},
+			orderBy: " ORDER BY name DESC, id DESC",
+		},
+		{
+			name:    "backward ascending",
+			sort:    "name",
+			cursor:  `{"s":"name,id","v":["bob",7],"b":true}`,
+			where:   " WHERE ((name < ?) OR (name = ? AND id < ?))",
+			args:    []any{"bob", "bob", int64(7)},
+			orderBy: " ORDER BY name DESC, id DESC",
+		},
+		{
+			name:    "backward descending",
+			sort:    "-id",
+			cursor:  `{"s":"-id","v":[7],"b":true}`,
+			where:   " WHERE ((id > ?))",
+			args:    []any{int64(7)},
+			orderBy: " ORDER BY id ASC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := mustQuery(t, Request{Sort: tt.sort, Cursor: rawCursor(tt.cursor)})
+
+			where, args := q.Where()
+			if where != tt.where {
+				t.Errorf("Where() = %q, want %q", where, tt.where)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Where() args = %#v, want %#v", args, tt.args)
+			}
+			if got := q.OrderBy(); got != tt.orderBy {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.orderBy)
+			}
+			if got := q.Limit(); got != " LIMIT 21" {
+				t.Errorf("Limit() = %q, cursor pages must not use an offset", got)
+			}
+
+			// Counting ignores the cursor position
+			if where, _ := q.CountWhere(); where != "" {
+				t.Errorf("CountWhere() = %q, want empty", where)
+			}
+		})
+	}
+}
+
+func TestInvalidCursors(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   string
+		cursor string
+	}{
+		{"not base64", "", "!!!"},
+		{"not json", "", rawCursor("not json")},
+		{"other sort", "name", rawCursor(`{"s":"id","v":[7]}`)},
+		{"missing value", "name", rawCursor(`{"s":"name,id","v":["bob"]}`)},
+		{"object value", "", rawCursor(`{"s":"id","v":[{"a":1}]}`)},
+		{"null value", "", rawCursor(`{"s":"id","v":[null]}`)},
+		{"string for an integer", "", rawCursor(`{"s":"id","v":["abc"]}`)},
+		{"fraction for an integer", "", rawCursor(`{"s":"id","v":[1.5]}`)},
+		{"integer out of range", "", rawCursor(`{"s":"id","v":[99999999999999999999]}`)},
+		{"bool for an integer", "", rawCursor(`{"s":"id","v":[true]}`)},
+		{"bool for a string", "name", rawCursor(`{"s":"name,id","v":[true,7]}`)},
+		{"malformed time", "created_at", rawCursor(`{"s":"created_at,id","v":["yesterday",7]}`)},
+		{"number for a time", "created_at", rawCursor(`{"s":"created_at,id","v":[1700000000,7]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := testSpec.Query(Request{Sort: tt.sort, Cursor: tt.cursor}); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Query error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorForFieldWithoutType(t *testing.T) {
+	spec := Spec[*item]{
+		Fields: map[string]Field[*item]{
+			"id":      {Column: "id", Value: func(i *item) any { return i.ID }},
+			"untyped": {Column: "untyped", Value: func(i *item) any { return nil }},
+		},
+		Default:    "id",
+		Tiebreaker: "id",
+	}
+
+	_, err := spec.Query(Request{Sort: "untyped", Cursor: rawCursor(`{"s":"untyped,id","v":["x",7]}`)})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Query error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestPaginateOffset(t *testing.T) {
+	items := []*item{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	tests := []struct {
+		name      string
+		req       Request
+		items     []*item
+		total     int64
+		wantItems int
+		wantMeta  Meta
+		wantNext  bool
+		wantPrev  bool
+	}{
+		{
+			name:      "first page with more",
+			req:       Request{PageSize: 2},
+			items:     items,
+			total:     5,
+			wantItems: 2,
+			wantMeta:  Meta{Page: 1, PageSize: 2, Total: 5, TotalPages: 3},
+			wantNext:  true,
+		},
+		{
+			name:      "middle page",
+			req:       Request{Page: 2, PageSize: 2},
+			items:     items,
+			total:     5,
+			wantItems: 2,
+			wantMeta:  Meta{Page: 2, PageSize: 2, Total: 5, TotalPages: 3},
+			wantNext:  true,
+			wantPrev:  true,
+		},
+		{
+			name:      "last page",
+			req:       Request{Page: 3, PageSize: 2},
+			items:     items[:1],
+			total:     5,
+			wantItems: 1,
+			wantMeta:  Meta{Page: 3, PageSize: 2, Total: 5, TotalPages: 3},
+			wantPrev:  true,
+		},
+		{
+			name:     "empty",
+			req:      Request{PageSize: 2},
+			wantMeta: Meta{Page: 1, PageSize: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, meta := mustQuery(t, tt.req).Paginate(append([]*item(nil), tt.items...), tt.total)
+
+			if len(page) != tt.wantItems {
+				t.Errorf("page has %d items, want %d", len(page), tt.wantItems)
+			}
+			if (meta.NextCursor != "") != tt.wantNext || (meta.PrevCursor != "") != tt.wantPrev {
+				t.Errorf("cursors next=%q prev=%q, want next=%v prev=%v", meta.NextCursor, meta.PrevCursor, tt.wantNext, tt.wantPrev)
+			}
+
+			meta.NextCursor, meta.PrevCursor = "", ""
+			if meta != tt.wantMeta {
+				t.Errorf("meta = %+v, want %+v", meta, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestPaginateCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	rows := []*item{
+		{ID: 9007199254740993, Name: "ann", CreatedAt: created},
+		{ID: 2, Name: "bob", CreatedAt: created.Add(time.Second)},
+		{ID: 3, Name: "cid", CreatedAt: created.Add(2 * time.Second)},
+	}
+
+	q := mustQuery(t, Request{Sort: "created_at", PageSize: 2})
+	page, meta := q.Paginate(append([]*item(nil), rows...), 3)
+	if len(page) != 2 || meta.NextCursor == "" || meta.PrevCursor != "" {
+		t.Fatalf("first page = %d items, meta %+v", len(page), meta)
+	}
+
+	// The next cursor continues after the last item of the page, with its values typed again
+	next := mustQuery(t, Request{Sort: "created_at", PageSize: 2, Cursor: meta.NextCursor})
+	_, args := next.Where()
+	wantArgs := []any{rows[1].CreatedAt, rows[1].CreatedAt, rows[1].ID}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("next page args = %#v, want %#v", args, wantArgs)
+	}
+
+	// The second page fetched only the remaining row and links back
+	page, meta = next.Paginate([]*item{rows[2]}, 3)
+	if len(page) != 1 || meta.NextCursor != "" || meta.PrevCursor == "" || meta.Page != 0 {
+		t.Fatalf("second page = %d items, meta %+v", len(page), meta)
+	}
+
+	// Going back continues before the first item of the second page, in reverse order
+	prev := mustQuery(t, Request{Sort: "created_at", PageSize: 2, Cursor: meta.PrevCursor})
+	where, args := prev.Where()
+	if where != " WHERE ((created_at < ?) OR (created_at = ? AND id < ?))" {
+		t.Errorf("previous page Where() = %q", where)
+	}
+	if !reflect.DeepEqual(args, []any{rows[2].CreatedAt, rows[2].CreatedAt, rows[2].ID}) {
+		t.Errorf("previous page args = %#v", args)
+	}
+	if got := prev.OrderBy(); got != " ORDER BY created_at DESC, id DESC" {
+		t.Errorf("previous page OrderBy() = %q", got)
+	}
+
+	// Backward rows arrive in reverse and are returned in sort order,
+	// the first page has nothing before it but a next page after it
+	page, meta = prev.Paginate([]*item{rows[1], rows[0]}, 3)
+	if page[0] != rows[0] || page[1] != rows[1] {
+		t.Errorf("backward page is not in sort order: %v, %v", page[0].ID, page[1].ID)
+	}
+	if meta.PrevCursor != "" || meta.NextCursor == "" {
+		t.Errorf("backward first page meta = %+v", meta)
+	}
+
+	// A backward page with more rows before it links further back
+	_, meta = prev.Paginate([]*item{rows[2], rows[1], rows[0]}, 3)
+	if meta.PrevCursor == "" {
+		t.Error("backward page with more rows has no previous cursor")
+	}
+}
@@ -0,0 +1,207 @@
+package password
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// BreachChecker reports whether a password appeared in a known data breach
+type BreachChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// Supported breach check providers
+const (
+	BreachProviderHIBP = "hibp"
+	BreachProviderFile = "file"
+)
+
+// DefaultHIBPURL is the Have I Been Pwned Pwned Passwords API
+const DefaultHIBPURL = "https://api.pwnedpasswords.com"
+
+// sha1Hex returns the uppercase hex encoded SHA-1 hash of the password
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// HIBPChecker checks passwords against the Pwned Passwords range API using
+// k-anonymity: only the first 5 characters of the password's SHA-1 hash are sent
+type HIBPChecker struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewHIBPChecker creates a checker for a Pwned Passwords compatible API,
+// an empty baseURL selects DefaultHIBPURL
+func NewHIBPChecker(baseURL string, timeout time.Duration) *HIBPChecker {
+	if baseURL == "" {
+		baseURL = DefaultHIBPURL
+	}
+
+	return &HIBPChecker{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *HIBPChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	hash := sha1Hex(password)
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/range/"+prefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create breach check request: %w", err)
+	}
+	// Padding hides the real number of suffixes sharing the prefix
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check password breach: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to check password breach: unexpected status %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lineSuffix, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		// Padding entries have a count of 0
+		if strings.EqualFold(lineSuffix, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read breach check response: %w", err)
+	}
+
+	return false, nil
+}
+
+// FileChecker checks passwords against a local file of SHA-1 hashes for
+// air-gapped deployments. The file holds one hash per line, optionally followed
+// by ":count", sorted by hash, e.g. the Pwned Passwords "ordered by hash"
+// download. Lookups binary search the file, so it is never loaded into memory
+type FileChecker struct {
+	file *os.File
+	size int64
+}
+
+// NewFileChecker opens a sorted hash file
+func NewFileChecker(path string) (*FileChecker, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat breached password file: %w", err)
+	}
+
+	return &FileChecker{file: file, size: info.Size()}, nil
+}
+
+// Close closes the hash file
+func (c *FileChecker) Close() error {
+	return c.file.Close()
+}
+
+func (c *FileChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	target := sha1Hex(password)
+
+	// Invariant: if the target's line exists it starts within [lo, hi)
+	lo, hi := int64(0), c.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start, err := c.lineStartFrom(mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		line, next, err := c.readLine(start)
+		if err != nil {
+			return false, err
+		}
+
+		hash, _, _ := strings.Cut(line, ":")
+		switch strings.Compare(strings.ToUpper(hash), target) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+
+	return false, nil
+}
+
+// lineStartFrom returns the offset of the first line starting at or after offset
+func (c *FileChecker) lineStartFrom(offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+
+	buf := make([]byte, 128)
+	pos := offset - 1
+	for pos < c.size {
+		n, err := c.file.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return pos + int64(i) + 1, nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return 0, fmt.Errorf("failed to read breached password file: %w", err)
+		}
+		pos += int64(n)
+	}
+
+	return c.size, nil
+}
+
+// readLine returns the line starting at offset and the offset of the next line
+func (c *FileChecker) readLine(offset int64) (string, int64, error) {
+	var line []byte
+	buf := make([]byte, 128)
+	pos := offset
+	for pos < c.size {
+		n, err := c.file.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			line = append(line, buf[:i]...)
+			return strings.TrimSpace(string(line)), pos + int64(i) + 1, nil
+		}
+		line = append(line, buf[:n]...)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return "", 0, fmt.Errorf("failed to read breached password file: %w", err)
+		}
+		pos += int64(n)
+	}
+
+	return strings.TrimSpace(string(line)), c.size, nil
+}
@@ -0,0 +1,210 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rangeServer serves a Pwned Passwords range response containing the given hashes
+// with their counts, plus padding entries with a count of 0
+func rangeServer(t *testing.T, counts map[string]int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix, ok := strings.CutPrefix(r.URL.Path, "/range/")
+		if !ok || len(prefix) != 5 {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("range request without Add-Padding header")
+		}
+
+		for hash, count := range counts {
+			if strings.HasPrefix(hash, prefix) {
+				fmt.Fprintf(w, "%s:%d\r\n", hash[5:], count)
+			}
+		}
+		fmt.Fprintf(w, "%s:0\r\n", strings.Repeat("0", 35))
+	}))
+}
+
+func TestHIBPChecker(t *testing.T) {
+	padded := "padded-only"
+	server := rangeServer(t, map[string]int{
+		sha1Hex("password"): 9545824,
+		sha1Hex(padded):     0,
+	})
+	defer server.Close()
+
+	checker := NewHIBPChecker(server.URL+"/", time.Second)
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"password", true},
+		{"correct horse battery staple 8f3a", false},
+		{padded, false},
+	}
+
+	for _, tt := range tests {
+		got, err := checker.IsBreached(context.Background(), tt.password)
+		if err != nil {
+			t.Fatalf("IsBreached(%q) returned error: %v", tt.password, err)
+		}
+		if got != tt.want {
+			t.Errorf("IsBreached(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestHIBPCheckerSendsOnlyThePrefix(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer server.Close()
+
+	if _, err := NewHIBPChecker(server.URL, time.Second).IsBreached(context.Background(), "password"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/range/" + sha1Hex("password")[:5]; path != want {
+		t.Errorf("requested %q, want %q", path, want)
+	}
+}
+
+func TestHIBPCheckerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewHIBPChecker(server.URL, time.Second).IsBreached(context.Background(), "password"); err == nil {
+		t.Error("IsBreached ignored an error status")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewHIBPChecker(server.URL, time.Second).IsBreached(ctx, "password"); err == nil {
+		t.Error("IsBreached ignored a cancelled context")
+	}
+}
+
+// writeHashFile writes the sorted SHA-1 hashes of the passwords, formatted by line
+func writeHashFile(t *testing.T, passwords []string, line func(i int, hash string) string) string {
+	t.Helper()
+
+	hashes := make([]string, 0, len(passwords))
+	for _, p := range passwords {
+		hashes = append(hashes, sha1Hex(p))
+	}
+	sort.Strings(hashes)
+
+	var b strings.Builder
+	for i, hash := range hashes {
+		b.WriteString(line(i, hash))
+	}
+
+	path := filepath.Join(t.TempDir(), "hashes.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileChecker(t *testing.T) {
+	breached := make([]string, 0, 500)
+	for i := 0; i < 500; i++ {
+		breached = append(breached, fmt.Sprintf("breached-%d", i))
+	}
+
+	formats := []struct {
+		name string
+		line func(i int, hash string) string
+	}{
+		{"plain", func(i int, hash string) string { return hash + "\n" }},
+		{"with counts", func(i int, hash string) string { return fmt.Sprintf("%s:%d\n", hash, i+1) }},
+		{"crlf", func(i int, hash string) string { return hash + ":1\r\n" }},
+		{"lower case", func(i int, hash string) string { return strings.ToLower(hash) + "\n" }},
+		{"no trailing newline", func(i int, hash string) string {
+			if i == len(breached)-1 {
+				return hash
+			}
+			return hash + "\n"
+		}},
+	}
+
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			checker, err := NewFileChecker(writeHashFile(t, breached, format.line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer checker.Close()
+
+			// Every entry is found, including the first and the last line
+			for _, p := range breached {
+				ok, err := checker.IsBreached(context.Background(), p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !ok {
+					t.Fatalf("IsBreached(%q) = false, want true", p)
+				}
+			}
+
+			for i := 0; i < 100; i++ {
+				p := fmt.Sprintf("not-breached-%d", i)
+				ok, err := checker.IsBreached(context.Background(), p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok {
+					t.Fatalf("IsBreached(%q) = true, want false", p)
+				}
+			}
+		})
+	}
+}
+
+func TestFileCheckerEdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		passwords []string
+	}{
+		{"empty file", nil},
+		{"single line", []string{"password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewFileChecker(writeHashFile(t, tt.passwords, func(i int, hash string) string { return hash + "\n" }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer checker.Close()
+
+			for _, p := range tt.passwords {
+				if ok, err := checker.IsBreached(context.Background(), p); err != nil || !ok {
+					t.Errorf("IsBreached(%q) = (%v, %v), want (true, nil)", p, ok, err)
+				}
+			}
+			if ok, err := checker.IsBreached(context.Background(), "unlisted"); err != nil || ok {
+				t.Errorf("IsBreached(unlisted) = (%v, %v), want (false, nil)", ok, err)
+			}
+		})
+	}
+
+	if _, err := NewFileChecker(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("NewFileChecker opened a missing file")
+	}
+}
@@ -0,0 +1,111 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PasswordHasher hashes passwords and verifies them against stored hashes
+// Hashes are self-describing strings in PHC / modular crypt format, so the
+// algorithm and parameters a hash was created with can be read back from it
+type PasswordHasher interface {
+	// Hash returns the encoded hash of the password
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the encoded hash
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash reports whether the hash was created with an outdated algorithm or parameters
+	NeedsRehash(encoded string) bool
+}
+
+// Supported hashing algorithms
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrUnsupportedHash = errors.New("unsupported password hash format")
+	ErrInvalidHash     = errors.New("invalid password hash")
+)
+
+// Config holds password hashing configuration
+// Algorithm selects how new hashes are created, hashes of every supported
+// algorithm can be verified
+type Config struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2id   Argon2idParams
+}
+
+// New creates a hasher that hashes with the configured algorithm, defaulting to argon2id
+func New(cfg Config) (PasswordHasher, error) {
+	bcryptHasher, err := NewBcryptHasher(cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2idHasher, err := NewArgon2idHasher(cfg.Argon2id)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &hasher{bcrypt: bcryptHasher, argon2id: argon2idHasher}
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id, "":
+		h.preferred = argon2idHasher
+	case AlgorithmBcrypt:
+		h.preferred = bcryptHasher
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm: %s", cfg.Algorithm)
+	}
+
+	return h, nil
+}
+
+// hasher creates hashes with the preferred algorithm and verifies hashes of any algorithm
+type hasher struct {
+	preferred PasswordHasher
+	bcrypt    *BcryptHasher
+	argon2id  *Argon2idHasher
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// Verify checks the password with the algorithm the hash was created with
+// An empty hash, e.g. of an account without a password, never matches
+func (h *hasher) Verify(password, encoded string) (bool, error) {
+	if encoded == "" {
+		return false, nil
+	}
+
+	algorithm, err := h.algorithmOf(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	return algorithm.Verify(password, encoded)
+}
+
+func (h *hasher) NeedsRehash(encoded string) bool {
+	algorithm, err := h.algorithmOf(encoded)
+	if err != nil {
+		return false
+	}
+
+	return algorithm != h.preferred || algorithm.NeedsRehash(encoded)
+}
+
+// algorithmOf returns the hasher able to verify the encoded hash
+func (h *hasher) algorithmOf(encoded string) (PasswordHasher, error) {
+	switch {
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		return h.argon2id, nil
+	case isBcryptHash(encoded):
+		return h.bcrypt, nil
+	default:
+		return nil, ErrUnsupportedHash
+	}
+}
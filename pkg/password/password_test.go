@@ -0,0 +1,188 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2id keeps the tests quick, the parameters are far below production values
+var fastArgon2id = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newTestHasher(t *testing.T, algorithm string) PasswordHasher {
+	t.Helper()
+	h, err := New(Config{Algorithm: algorithm, BcryptCost: bcrypt.MinCost, Argon2id: fastArgon2id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHashAndVerify(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		prefix    string
+	}{
+		{AlgorithmArgon2id, "$argon2id$v=19$m=64,t=1,p=1$"},
+		{AlgorithmBcrypt, "$2a$04$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			h := newTestHasher(t, tt.algorithm)
+
+			encoded, err := h.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(encoded, tt.prefix) {
+				t.Fatalf("hash %q does not start with %q", encoded, tt.prefix)
+			}
+
+			if ok, err := h.Verify("correct horse battery staple", encoded); err != nil || !ok {
+				t.Errorf("Verify(correct) = (%v, %v), want (true, nil)", ok, err)
+			}
+			if ok, err := h.Verify("wrong password", encoded); err != nil || ok {
+				t.Errorf("Verify(wrong) = (%v, %v), want (false, nil)", ok, err)
+			}
+		})
+	}
+}
+
+func TestHashesAreSalted(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id)
+
+	first, _ := h.Hash("password")
+	second, _ := h.Hash("password")
+	if first == second {
+		t.Error("hashing the same password twice produced the same hash")
+	}
+}
+
+func TestVerifyAcceptsEveryAlgorithm(t *testing.T) {
+	argon2idHasher := newTestHasher(t, AlgorithmArgon2id)
+	bcryptHasher := newTestHasher(t, AlgorithmBcrypt)
+
+	argon2idHash, _ := argon2idHasher.Hash("password")
+	bcryptHash, _ := bcryptHasher.Hash("password")
+
+	for _, h := range []PasswordHasher{argon2idHasher, bcryptHasher} {
+		for _, encoded := range []string{argon2idHash, bcryptHash} {
+			if ok, err := h.Verify("password", encoded); err != nil || !ok {
+				t.Errorf("Verify(%q) = (%v, %v), want (true, nil)", encoded, ok, err)
+			}
+		}
+	}
+}
+
+func TestVerifyRejectsInvalidHashes(t *testing.T) {
+	h := newTestHasher(t, AlgorithmArgon2id)
+
+	tests := []struct {
+		name    string
+		encoded string
+		err     error
+	}{
+		{"empty", "", nil},
+		{"unknown format", "$1$salt$hash", ErrUnsupportedHash},
+		{"plain text", "password", ErrUnsupportedHash},
+		{"argon2id missing parts", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ", ErrInvalidHash},
+		{"argon2id other version", "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5", ErrUnsupportedHash},
+		{"argon2id zero memory", "$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5", ErrInvalidHash},
+		{"argon2id bad salt", "$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5a2V5a2V5a2V5", ErrInvalidHash},
+		{"bcrypt truncated", "$2a$04$short", ErrInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := h.Verify("password", tt.encoded)
+			if ok {
+				t.Fatal("Verify matched an invalid hash")
+			}
+			if tt.err == nil && err != nil {
+				t.Fatalf("Verify error = %v, want nil", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current := newTestHasher(t, AlgorithmArgon2id)
+
+	stronger, err := New(Config{
+		Algorithm:  AlgorithmArgon2id,
+		BcryptCost: bcrypt.MinCost,
+		Argon2id:   Argon2idParams{Memory: 128, Iterations: 1, Parallelism: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHasher := newTestHasher(t, AlgorithmBcrypt)
+	costlierBcrypt, err := New(Config{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1, Argon2id: fastArgon2id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	argon2idHash, _ := current.Hash("password")
+	bcryptHash, _ := bcryptHasher.Hash("password")
+
+	tests := []struct {
+		name    string
+		hasher  PasswordHasher
+		encoded string
+		want    bool
+	}{
+		{"same argon2id parameters", current, argon2idHash, false},
+		{"stronger argon2id parameters", stronger, argon2idHash, true},
+		{"bcrypt hash with argon2id preferred", current, bcryptHash, true},
+		{"same bcrypt cost", bcryptHasher, bcryptHash, false},
+		{"higher bcrypt cost", costlierBcrypt, bcryptHash, true},
+		{"argon2id hash with bcrypt preferred", bcryptHasher, argon2idHash, true},
+		{"unknown format", current, "$1$salt$hash", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.encoded); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"unknown algorithm", Config{Algorithm: "md5"}},
+		{"bcrypt cost too low", Config{BcryptCost: bcrypt.MinCost - 1}},
+		{"bcrypt cost too high", Config{BcryptCost: bcrypt.MaxCost + 1}},
+		{"argon2id memory too low", Config{Argon2id: Argon2idParams{Memory: 4, Parallelism: 1}}},
+		{"argon2id salt too short", Config{Argon2id: Argon2idParams{SaltLength: 4}}},
+		{"argon2id key too short", Config{Argon2id: Argon2idParams{KeyLength: 8}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New accepted an invalid config")
+			}
+		})
+	}
+}
+
+func TestArgon2idDefaults(t *testing.T) {
+	h, err := NewArgon2idHasher(Argon2idParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.params != DefaultArgon2idParams {
+		t.Errorf("params = %+v, want %+v", h.params, DefaultArgon2idParams)
+	}
+}
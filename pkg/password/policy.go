@@ -0,0 +1,148 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	appErrors "github.com/ozaanmetin/go-microservice-starter/pkg/errors"
+)
+
+// Policy describes the rules new passwords must follow
+// Zero values disable a rule
+type Policy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	DisallowEmail    bool
+}
+
+// Rules reported in violations
+const (
+	RuleMinLength     = "min_length"
+	RuleMaxLength     = "max_length"
+	RuleUppercase     = "uppercase"
+	RuleLowercase     = "lowercase"
+	RuleDigit         = "digit"
+	RuleSymbol        = "symbol"
+	RuleContainsEmail = "contains_email"
+	RuleReused        = "reused"
+	RuleBreached      = "breached"
+)
+
+// minEmailPartLength keeps very short email local parts from rejecting most passwords
+const minEmailPartLength = 3
+
+// Violation is a single broken password rule
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError is returned when a password breaks one or more rules
+type PolicyError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface
+func (e *PolicyError) Error() string {
+	return "password policy violated: " + strings.Join(e.Messages(), "; ")
+}
+
+// Messages returns the messages of every violation
+func (e *PolicyError) Messages() []string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return messages
+}
+
+// ToServiceError maps a policy error to a 422 ServiceError reported on the given request field
+// It returns nil for errors that are not policy errors
+func ToServiceError(field string, err error) *appErrors.ServiceError {
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		return nil
+	}
+
+	return appErrors.NewValidationError("Password does not meet the password policy", err).
+		AddDetail(field, map[string]interface{}{
+			"rule":       "password_policy",
+			"message":    strings.Join(policyErr.Messages(), "; "),
+			"violations": policyErr.Violations,
+		})
+}
+
+// Check returns the rules the password breaks, email is the account's address
+func (p Policy) Check(password, email string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("Must be at least %d characters long", p.MinLength),
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("Must be at most %d characters long", p.MaxLength),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Message: "Must contain an uppercase letter"})
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Message: "Must contain a lowercase letter"})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "Must contain a digit"})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "Must contain a symbol"})
+	}
+
+	if p.DisallowEmail && containsEmail(password, email) {
+		violations = append(violations, Violation{Rule: RuleContainsEmail, Message: "Must not contain your email address"})
+	}
+
+	return violations
+}
+
+// containsEmail reports whether the password contains the email address or its local part
+func containsEmail(password, email string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+
+	if strings.Contains(password, email) {
+		return true
+	}
+
+	localPart, _, _ := strings.Cut(email, "@")
+	return utf8.RuneCountInString(localPart) >= minEmailPartLength && strings.Contains(password, localPart)
+}
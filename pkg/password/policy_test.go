@@ -0,0 +1,93 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	strict := Policy{
+		MinLength:        10,
+		MaxLength:        20,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowEmail:    true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		email    string
+		want     []string
+	}{
+		{"satisfies every rule", strict, "Sunny-Day-42", "jane@example.com", nil},
+		{"empty policy accepts anything", Policy{}, "a", "jane@example.com", nil},
+		{"too short", strict, "Sun-4y", "", []string{RuleMinLength}},
+		{"too long", strict, "Sunny-Day-42-Sunny-Day-42", "", []string{RuleMaxLength}},
+		{"length counts characters not bytes", Policy{MinLength: 4}, "ğüşö", "", nil},
+		{"missing uppercase", strict, "sunny-day-42", "", []string{RuleUppercase}},
+		{"missing lowercase", strict, "SUNNY-DAY-42", "", []string{RuleLowercase}},
+		{"missing digit", strict, "Sunny-Day-xx", "", []string{RuleDigit}},
+		{"missing symbol", strict, "SunnyDay4242", "", []string{RuleSymbol}},
+		{"space counts as symbol", strict, "Sunny Day 42", "", nil},
+		{"several violations", strict, "sunny", "", []string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol}},
+		{"contains email", strict, "Jane@Example.com1", "jane@example.com", []string{RuleContainsEmail}},
+		{"contains local part", strict, "My-JANE-pass-1", "jane@example.com", []string{RuleContainsEmail}},
+		{"short local part is ignored", strict, "Sunny-Jo-Day-1", "jo@example.com", nil},
+		{"email rule disabled", Policy{}, "jane@example.com", "jane@example.com", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range tt.policy.Check(tt.password, tt.email) {
+				got = append(got, v.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) rules = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyErrorMessages(t *testing.T) {
+	err := &PolicyError{Violations: []Violation{
+		{Rule: RuleMinLength, Message: "Must be at least 10 characters long"},
+		{Rule: RuleDigit, Message: "Must contain a digit"},
+	}}
+
+	want := "password policy violated: Must be at least 10 characters long; Must contain a digit"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestToServiceError(t *testing.T) {
+	policyErr := &PolicyError{Violations: []Violation{{Rule: RuleBreached, Message: "Has appeared in a data breach"}}}
+
+	serviceErr := ToServiceError("new_password", fmt.Errorf("failed to change password: %w", policyErr))
+	if serviceErr == nil {
+		t.Fatal("ToServiceError returned nil for a wrapped policy error")
+	}
+	if serviceErr.Code != "validation_error" || serviceErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("ToServiceError = %s/%d, want a 422 validation error", serviceErr.Code, serviceErr.StatusCode)
+	}
+
+	detail, ok := serviceErr.Details["new_password"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("details = %#v, want an entry for new_password", serviceErr.Details)
+	}
+	if detail["rule"] != "password_policy" || !reflect.DeepEqual(detail["violations"], policyErr.Violations) {
+		t.Errorf("detail = %#v", detail)
+	}
+
+	if ToServiceError("password", errors.New("database unavailable")) != nil {
+		t.Error("ToServiceError mapped an error that is not a policy error")
+	}
+}
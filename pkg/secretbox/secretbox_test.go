@@ -0,0 +1,121 @@
+package secretbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestBox(t *testing.T) *Box {
+	t.Helper()
+	box, err := New(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
+
+func TestNewKeySizes(t *testing.T) {
+	tests := []struct {
+		size  int
+		valid bool
+	}{
+		{0, false},
+		{15, false},
+		{16, true},
+		{24, true},
+		{32, true},
+		{33, false},
+	}
+
+	for _, tt := range tests {
+		_, err := New(make([]byte, tt.size))
+		if tt.valid && err != nil {
+			t.Errorf("New with %d byte key returned error: %v", tt.size, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("New with %d byte key error = %v, want ErrInvalidKey", tt.size, err)
+		}
+	}
+}
+
+func TestNewFromBase64(t *testing.T) {
+	if _, err := NewFromBase64(base64.StdEncoding.EncodeToString(make([]byte, 32))); err != nil {
+		t.Errorf("NewFromBase64 returned error: %v", err)
+	}
+	if _, err := NewFromBase64("not base64!"); err == nil {
+		t.Error("NewFromBase64 accepted an invalid encoding")
+	}
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	box := newTestBox(t)
+
+	for _, plaintext := range [][]byte{{}, []byte("JBSWY3DPEHPK3PXP"), bytes.Repeat([]byte("x"), 4096)} {
+		sealed, err := box.Seal(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opened, err := box.Open(sealed)
+		if err != nil {
+			t.Fatalf("Open returned error: %v", err)
+		}
+		if !bytes.Equal(opened, plaintext) {
+			t.Errorf("Open = %q, want %q", opened, plaintext)
+		}
+	}
+}
+
+func TestSealUsesFreshNonces(t *testing.T) {
+	box := newTestBox(t)
+
+	first, _ := box.Seal([]byte("secret"))
+	second, _ := box.Seal([]byte("secret"))
+	if first == second {
+		t.Error("sealing the same plaintext twice produced the same ciphertext")
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	box := newTestBox(t)
+	sealed, err := box.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(sealed)
+
+	flip := func(i int) string {
+		tampered := append([]byte(nil), data...)
+		tampered[i] ^= 1
+		return base64.StdEncoding.EncodeToString(tampered)
+	}
+
+	otherBox, err := New(bytes.Repeat([]byte{8}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSealed, _ := otherBox.Seal([]byte("secret"))
+
+	tests := []struct {
+		name   string
+		sealed string
+	}{
+		{"flipped nonce", flip(0)},
+		{"flipped ciphertext", flip(len(data) / 2)},
+		{"flipped tag", flip(len(data) - 1)},
+		{"truncated", base64.StdEncoding.EncodeToString(data[:len(data)-1])},
+		{"shorter than nonce", base64.StdEncoding.EncodeToString(data[:4])},
+		{"not base64", "not base64!"},
+		{"other key", otherSealed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := box.Open(tt.sealed); !errors.Is(err, ErrInvalidCiphertext) {
+				t.Errorf("Open error = %v, want ErrInvalidCiphertext", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 encoding of the RFC 4226 / RFC 6238 SHA1 test key "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPMatchesRFC4226Vectors(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range want {
+		got, err := Code(rfcSecret, int64(counter))
+		if err != nil {
+			t.Fatalf("Code(%d) returned error: %v", counter, err)
+		}
+		if got != code {
+			t.Errorf("Code(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 lists 8 digit codes, the last 6 digits are the 6 digit codes
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		at := time.Unix(tt.unix, 0)
+		want := tt.code[len(tt.code)-Digits:]
+
+		got, err := Code(rfcSecret, Step(at))
+		if err != nil {
+			t.Fatalf("Code at %d returned error: %v", tt.unix, err)
+		}
+		if got != want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, want)
+		}
+
+		step, ok, err := Validate(rfcSecret, want, at)
+		if err != nil || !ok || step != Step(at) {
+			t.Errorf("Validate at %d = (%d, %v, %v), want (%d, true, nil)", tt.unix, step, ok, err, Step(at))
+		}
+	}
+}
+
+func TestValidateSkewWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name   string
+		offset int64
+		valid  bool
+	}{
+		{"previous step", -1, true},
+		{"current step", 0, true},
+		{"next step", 1, true},
+		{"two steps behind", -2, false},
+		{"two steps ahead", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			step, ok, err := Validate(rfcSecret, code, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.valid {
+				t.Fatalf("Validate = %v, want %v", ok, tt.valid)
+			}
+			if ok && step != current+tt.offset {
+				t.Errorf("matched step %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok, err := Validate(rfcSecret, code, now); err != nil || ok {
+			t.Errorf("Validate(%q) = (%v, %v), want (false, nil)", code, ok, err)
+		}
+	}
+
+	// Surrounding whitespace is ignored
+	if _, ok, _ := Validate(rfcSecret, " 287082 ", now); !ok {
+		t.Error("Validate rejected a code with surrounding whitespace")
+	}
+}
+
+func TestSecretDecoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		valid  bool
+	}{
+		{"canonical", rfcSecret, true},
+		{"lower case with spaces", strings.ToLower("GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ"), true},
+		{"padded", "GEZDGNBVGY3TQOJQ====", true},
+		{"empty", "", false},
+		{"not base32", "not-a-secret!", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Code(tt.secret, 0)
+			if tt.valid && err != nil {
+				t.Fatalf("Code returned error: %v", err)
+			}
+			if !tt.valid && err != ErrInvalidSecret {
+				t.Fatalf("Code error = %v, want ErrInvalidSecret", err)
+			}
+		})
+	}
+}
+
+func TestGenerateSecretRoundTrip(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		t.Fatalf("generated secret does not decode: %v", err)
+	}
+	if len(key) != SecretSize {
+		t.Errorf("secret has %d bytes, want %d", len(key), SecretSize)
+	}
+}